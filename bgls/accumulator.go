@@ -0,0 +1,76 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// Accumulator accumulates pairings to check as one product, so that several
+// verifications share a single final exponentiation. For example, an aggregate
+// signature is checked by adding each message's pairing with its key, and the
+// pairing of the negated signature with the G2 generator, then checking that
+// the product is the identity.
+//
+// The product only shows that the combined equation holds, so an invalid
+// signature in one check could cancel out another. AddAggregateSignature
+// prevents this by scaling each check by an independent random scalar, and
+// checks added by hand with AddPairing should be scaled in the same way.
+type Accumulator struct {
+	curve CurveSystem
+	pairs []PairInput
+}
+
+// NewAccumulator creates an empty Accumulator for the curve.
+func NewAccumulator(curve CurveSystem) *Accumulator {
+	requireCurve(curve, "NewAccumulator")
+	return &Accumulator{curve: curve}
+}
+
+// AddPairing adds the pairing of g1 and g2 to the product.
+func (a *Accumulator) AddPairing(g1, g2 Point) {
+	a.pairs = append(a.pairs, PairInput{G1: g1, G2: g2})
+}
+
+// AddMsgPairing adds the pairing of the message's hash with key to the product.
+func (a *Accumulator) AddMsgPairing(msg []byte, key Point) {
+	a.AddPairing(a.curve.HashToG1(msg), key)
+}
+
+// AddAggregateSignature adds the pairings which check the aggregate signature
+// on the messages by the keys, whose product is the identity if it is valid.
+// As with VerifyAggregateSignature, the messages must be distinct. It returns
+// false without adding anything if the aggregate can't be valid. The check's
+// G1 points are scaled by a fresh random 128 bit weight, as in
+// VerifyTwoSignatures, so that invalid aggregates can't cancel each other out.
+func (a *Accumulator) AddAggregateSignature(aggsig Point, keys []Point, msgs [][]byte) bool {
+	pts1, pts2, ok := aggSigPairingInputs(a.curve, aggsig, keys, msgs, false, a.curve.HashToG1)
+	if !ok {
+		return false
+	}
+	r, err := rand.Int(rand.Reader, batchWeightBound)
+	if err != nil {
+		return false
+	}
+	r.Add(r, big.NewInt(1))
+	for i := 0; i < len(pts1); i++ {
+		a.AddPairing(pts1[i].Mul(r), pts2[i])
+	}
+	return true
+}
+
+// FinalizeEquals checks whether the product of the accumulated pairings is
+// target, with a single final exponentiation. Checks added with
+// AddAggregateSignature hold if the product is the identity of GT.
+func (a *Accumulator) FinalizeEquals(target PointT) bool {
+	product, ok := a.curve.MultiMillerLoop(a.pairs)
+	if !ok {
+		return false
+	}
+	product = a.curve.FinalExponentiation(product)
+	return product != nil && product.Equals(target)
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccumulator(t *testing.T) {
+	for _, curve := range curves {
+		A, N, Size := 3, 4, 32
+		aggsigs := make([]Point, A)
+		keys := make([][]Point, A)
+		msgs := make([][][]byte, A)
+		for j := 0; j < A; j++ {
+			keys[j] = make([]Point, N)
+			msgs[j] = make([][]byte, N)
+			sigs := make([]Point, N)
+			for i := 0; i < N; i++ {
+				msgs[j][i] = make([]byte, Size)
+				rand.Read(msgs[j][i])
+				sk, vk, _ := KeyGen(curve)
+				keys[j][i] = vk
+				sigs[i] = Sign(curve, sk, msgs[j][i])
+			}
+			aggsigs[j] = AggregateSignatures(sigs)
+		}
+
+		acc := NewAccumulator(curve)
+		for j := 0; j < A; j++ {
+			assert.True(t, VerifyAggregateSignature(curve, aggsigs[j], keys[j], msgs[j]))
+			assert.True(t, acc.AddAggregateSignature(aggsigs[j], keys[j], msgs[j]))
+		}
+		assert.True(t, acc.FinalizeEquals(curve.GetGTIdentity()), "Accumulated aggregates failed verification")
+
+		acc = NewAccumulator(curve)
+		acc.AddAggregateSignature(aggsigs[0], keys[0], msgs[0])
+		acc.AddAggregateSignature(aggsigs[1], keys[1], msgs[2])
+		assert.False(t, acc.FinalizeEquals(curve.GetGTIdentity()), "Accumulated invalid aggregate verified")
+		assert.False(t, acc.AddAggregateSignature(aggsigs[0], keys[0][1:], msgs[0]), "Mismatched aggregate was added")
+
+		// Invalid aggregates whose errors cancel out are still rejected
+		bad0, _ := aggsigs[0].Add(curve.GetG1())
+		bad1, _ := aggsigs[1].Add(curve.GetG1().Neg())
+		assert.False(t, VerifyAggregateSignature(curve, bad0, keys[0], msgs[0]))
+		acc = NewAccumulator(curve)
+		acc.AddAggregateSignature(bad0, keys[0], msgs[0])
+		acc.AddAggregateSignature(bad1, keys[1], msgs[1])
+		assert.False(t, acc.FinalizeEquals(curve.GetGTIdentity()), "Cancelling invalid aggregates verified")
+
+		// Accumulating pairings by hand gives the pairing of a signature
+		sk, vk, _ := KeyGen(curve)
+		sig := Sign(curve, sk, msgs[0][0])
+		acc = NewAccumulator(curve)
+		acc.AddMsgPairing(msgs[0][0], vk)
+		target, _ := curve.Pair(sig, curve.GetG2())
+		assert.True(t, acc.FinalizeEquals(target), "Accumulated message pairing differs from the signature's pairing")
+		acc.AddPairing(sig.Neg(), curve.GetG2())
+		assert.True(t, acc.FinalizeEquals(curve.GetGTIdentity()), "Accumulated signature check failed")
+	}
+}
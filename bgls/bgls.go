@@ -4,13 +4,22 @@
 package bgls
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
 	"math/big"
+	"runtime"
 	"sync"
+	"time"
 
 	. "github.com/orbs-network/bgls/curves" // nolint: golint
 )
 
+// batchWeightBound bounds the random weights used in batched verification.
+var batchWeightBound = new(big.Int).Lsh(big.NewInt(1), 128)
+
 //MultiSig holds set of keys and one message plus signature
 type MultiSig struct {
 	keys []Point
@@ -18,6 +27,18 @@ type MultiSig struct {
 	msg  []byte
 }
 
+//Sig returns a copy of the multi signature's signature, e.g. to aggregate it
+//with other signatures.
+func (m MultiSig) Sig() Point {
+	return m.sig.Copy()
+}
+
+//Keys returns the keys of the multi signature. The slice is a copy, so it can be
+//modified freely.
+func (m MultiSig) Keys() []Point {
+	return append([]Point{}, m.keys...)
+}
+
 //AggSig holds paired sequences of keys and messages, and one signature
 type AggSig struct {
 	keys []Point
@@ -25,8 +46,97 @@ type AggSig struct {
 	sig  Point
 }
 
+//AggSigComponent is one signer's contribution to an AggSig
+type AggSigComponent struct {
+	Key Point
+	Msg []byte
+}
+
+//Components returns the key and message pairs of the aggregate signature, in
+//order. The messages are copies, so they can be modified freely.
+func (a *AggSig) Components() []AggSigComponent {
+	components := make([]AggSigComponent, len(a.keys))
+	for i := 0; i < len(a.keys); i++ {
+		components[i] = AggSigComponent{Key: a.keys[i], Msg: append([]byte{}, a.msgs[i]...)}
+	}
+	return components
+}
+
+//Signature returns the aggregated signature of the aggregate signature.
+func (a *AggSig) Signature() Point {
+	return a.sig
+}
+
+//AggregateKey returns the sum of all of the public keys in the aggregate
+//signature. This isn't used for verification, since each key signed its own
+//message, but is useful as a commitment to the set of signers.
+func (a *AggSig) AggregateKey() Point {
+	return AggregatePoints(a.keys)
+}
+
+// ErrNilPoint is returned when an aggregate signature's signature or a key is nil.
+var ErrNilPoint = errors.New("aggregate signature contains a nil point")
+
+// ErrNotInSubgroup is returned when a point isn't in its prime order subgroup.
+var ErrNotInSubgroup = errors.New("point isn't in the correct subgroup")
+
+// ErrDegenerateAggregate is returned when an aggregate signature has no keys, or
+// its signature is the identity.
+var ErrDegenerateAggregate = errors.New("aggregate signature is the identity or has no keys")
+
+// ErrDuplicateMessage is returned when an aggregate signature has a message twice.
+var ErrDuplicateMessage = errors.New("aggregate signature contains duplicate messages")
+
+//Validate cheaply checks the structure of the aggregate signature, without any
+//pairings, and returns the first problem found. It checks that there is one
+//message per key, that no point is nil, that every point is in its subgroup, that
+//the aggregate isn't degenerate, and that no message is repeated. An aggregate
+//which fails Validate also fails Verify, but passing doesn't imply it's valid.
+func (a *AggSig) Validate() error {
+	if len(a.keys) != len(a.msgs) {
+		return ErrLengthMismatch
+	}
+	if a.sig == nil {
+		return ErrNilPoint
+	}
+	for _, key := range a.keys {
+		if key == nil {
+			return ErrNilPoint
+		}
+	}
+	if !a.sig.InCorrectSubgroup() {
+		return ErrNotInSubgroup
+	}
+	for _, key := range a.keys {
+		if !key.InCorrectSubgroup() {
+			return ErrNotInSubgroup
+		}
+	}
+	if degenerateAggregate(a.sig.Curve(), a.sig, len(a.keys)) {
+		return ErrDegenerateAggregate
+	}
+	if containsDuplicateMessage(a.msgs) {
+		return ErrDuplicateMessage
+	}
+	return nil
+}
+
+// ErrNilCurve is returned when a nil CurveSystem is supplied.
+var ErrNilCurve = errors.New("bgls: curve is nil")
+
+// requireCurve panics with a clear message naming the caller if curve is nil,
+// rather than leaving the caller to hit a nil pointer dereference.
+func requireCurve(curve CurveSystem, caller string) {
+	if curve == nil {
+		panic("bgls: " + caller + " called with a nil curve")
+	}
+}
+
 //KeyGen generates a *big.Int and Point2
 func KeyGen(curve CurveSystem) (*big.Int, Point, error) {
+	if curve == nil {
+		return nil, nil, ErrNilCurve
+	}
 	x, err := rand.Int(rand.Reader, curve.GetG1Order())
 	if err != nil {
 		return nil, nil, err
@@ -37,12 +147,81 @@ func KeyGen(curve CurveSystem) (*big.Int, Point, error) {
 
 //LoadPublicKey turns secret key into a public key of type Point2
 func LoadPublicKey(curve CurveSystem, sk *big.Int) Point {
+	requireCurve(curve, "LoadPublicKey")
 	pubKey := curve.GetG2().Mul(sk)
 	return pubKey
 }
 
+// PublicKeyMatches checks that pubKey is the public key corresponding to the
+// secret key sk, by recomputing it with LoadPublicKey.
+func PublicKeyMatches(curve CurveSystem, sk *big.Int, pubKey Point) bool {
+	if pubKey == nil {
+		return false
+	}
+	return LoadPublicKey(curve, sk).Equals(pubKey)
+}
+
+// secretKeySize is the number of bytes in a fixed width secret key encoding.
+const secretKeySize = 32
+
+// ErrInvalidSecretKey is returned for a secret key that is out of range, e.g.
+// negative, or longer than 32 bytes.
+var ErrInvalidSecretKey = errors.New("secret key is out of range")
+
+// secretKeyBytes returns the fixed width, 32 byte, big endian encoding of sk.
+// This fails if sk is negative or doesn't fit in 32 bytes.
+func secretKeyBytes(sk *big.Int) ([]byte, bool) {
+	if sk == nil || sk.Sign() < 0 || sk.BitLen() > 8*secretKeySize {
+		return nil, false
+	}
+	encoded := make([]byte, secretKeySize)
+	raw := sk.Bytes()
+	copy(encoded[secretKeySize-len(raw):], raw)
+	return encoded, true
+}
+
+// SecretKeyEqual compares two secret keys in constant time, by comparing their
+// fixed width, 32 byte, big endian encodings. Keys which are negative or don't
+// fit in 32 bytes are never equal to anything.
+func SecretKeyEqual(a, b *big.Int) bool {
+	aBytes, aOk := secretKeyBytes(a)
+	bBytes, bOk := secretKeyBytes(b)
+	if !aOk || !bOk {
+		return false
+	}
+	return subtle.ConstantTimeCompare(aBytes, bBytes) == 1
+}
+
+// appKeyTag prefixes the hash input in DeriveAppKey.
+var appKeyTag = []byte("BGLS_APP_KEY")
+
+// DeriveAppKey deterministically derives a secret key scoped to an application
+// from a master secret key, and returns it with its public key. The child key is
+// sha512 of a tag, the master key's 32 byte encoding and appID, reduced into
+// [1, order-1], so keys for different appIDs are independent, and neither reveals
+// the master key. ErrInvalidSecretKey is returned for a negative master key, or
+// one that doesn't fit in 32 bytes.
+func DeriveAppKey(curve CurveSystem, master *big.Int, appID []byte) (*big.Int, Point, error) {
+	if curve == nil {
+		return nil, nil, ErrNilCurve
+	}
+	masterBytes, ok := secretKeyBytes(master)
+	if !ok {
+		return nil, nil, ErrInvalidSecretKey
+	}
+	h := sha512.New()
+	h.Write(appKeyTag)
+	h.Write(masterBytes)
+	h.Write(appID)
+	orderMinusOne := new(big.Int).Sub(curve.GetG1Order(), big.NewInt(1))
+	sk := new(big.Int).SetBytes(h.Sum(nil))
+	sk.Mod(sk, orderMinusOne).Add(sk, big.NewInt(1))
+	return sk, LoadPublicKey(curve, sk), nil
+}
+
 // Sign creates a standard BLS signature on a message with a private key
 func Sign(curve CurveSystem, sk *big.Int, msg []byte) Point {
+	requireCurve(curve, "Sign")
 	return SignCustHash(sk, msg, curve.HashToG1)
 }
 
@@ -54,20 +233,148 @@ func SignCustHash(sk *big.Int, msg []byte, hash func([]byte) Point) Point {
 	return i
 }
 
+// SignBatch creates standard BLS signatures on each of the messages with the
+// same private key. The hashing and scaling is spread across a pool of workers,
+// and sigs[i] is the signature on msgs[i].
+func SignBatch(curve CurveSystem, sk *big.Int, msgs [][]byte) []Point {
+	return signBatch(len(msgs), func(i int) Point { return Sign(curve, sk, msgs[i]) })
+}
+
+// SignAndAggregate returns the aggregate of standard BLS signatures on each of
+// the messages with the same private key. Since each signature is sk times the
+// message's hash, this hashes the messages across a pool of workers, and scales
+// the sum of the hashes by sk once. The result verifies with
+// VerifyAggregateSignature against the key repeated once per message, which
+// requires the messages to be distinct. It returns ErrDegenerateAggregate if
+// there are no messages.
+func SignAndAggregate(curve CurveSystem, sk *big.Int, msgs [][]byte) (Point, error) {
+	if curve == nil {
+		return nil, ErrNilCurve
+	}
+	if len(msgs) == 0 {
+		return nil, ErrDegenerateAggregate
+	}
+	sum, err := SafeAggregatePoints(HashToG1Batch(curve, msgs))
+	if err != nil {
+		return nil, err
+	}
+	return sum.Mul(sk), nil
+}
+
+// ErrLengthMismatch is returned when the keys and messages to sign differ in number.
+var ErrLengthMismatch = errors.New("number of keys and messages differ")
+
+// SignMany creates standard BLS signatures where sigs[i] is the signature on
+// msgs[i] with sks[i]. As with SignBatch, the signing is spread across a pool of
+// workers.
+func SignMany(curve CurveSystem, sks []*big.Int, msgs [][]byte) ([]Point, error) {
+	requireCurve(curve, "SignMany")
+	if len(sks) != len(msgs) {
+		return nil, ErrLengthMismatch
+	}
+	return signBatch(len(msgs), func(i int) Point { return Sign(curve, sks[i], msgs[i]) }), nil
+}
+
+// signBatch creates n signatures across a pool of workers, where sign(i)
+// creates the i'th signature.
+func signBatch(n int, sign func(int) Point) []Point {
+	sigs := make([]Point, n)
+	workers := runtime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+	indices := make(chan int, n)
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go concurrentSign(sign, sigs, indices, &wg)
+	}
+	wg.Wait()
+	return sigs
+}
+
+// concurrentSign creates the signatures whose indices are received on the channel.
+func concurrentSign(sign func(int) Point, sigs []Point, indices chan int, wg *sync.WaitGroup) {
+	for i := range indices {
+		sigs[i] = sign(i)
+	}
+	wg.Done()
+}
+
 // VerifySingleSignature checks that a single standard BLS signature is valid
 func VerifySingleSignature(curve CurveSystem, sig Point, pubKey Point, msg []byte) bool {
+	requireCurve(curve, "VerifySingleSignature")
 	return VerifySingleSignatureCustHash(curve, sig, pubKey, msg, curve.HashToG1)
 }
 
 // VerifySingleSignatureCustHash checks that a single standard BLS signature is
 // valid, using the supplied hash function to hash onto the curve where signatures lie.
+// This fails if the signature or key don't belong to curve.
 func VerifySingleSignatureCustHash(curve CurveSystem, sig Point, pubkey Point,
 	msg []byte, hash func([]byte) Point) bool {
+	requireCurve(curve, "VerifySingleSignatureCustHash")
+	if CheckCurve(curve, sig, pubkey) != nil {
+		return false
+	}
 	h := hash(msg).Mul(new(big.Int).SetInt64(-1))
 	paired, _ := curve.PairingProduct([]Point{h, sig}, []Point{pubkey, curve.GetG2()})
 	return curve.GetGTIdentity().Equals(paired)
 }
 
+// VerifyAgainstAny checks whether sig is a standard BLS signature on msg by any
+// of the keys, e.g. during key rotation when either a validator's old or new key
+// may have signed. The message is hashed once, and the signature paired once,
+// then compared with the pairing of the hash and each key in turn. It returns
+// the index of the first key which signed, or -1 and false if none did.
+func VerifyAgainstAny(curve CurveSystem, pubKeys []Point, msg []byte, sig Point) (int, bool) {
+	requireCurve(curve, "VerifyAgainstAny")
+	if CheckCurve(curve, sig) != nil || CheckCurve(curve, pubKeys...) != nil {
+		return -1, false
+	}
+	target, ok := curve.Pair(sig, curve.GetG2())
+	if !ok {
+		return -1, false
+	}
+	h := curve.HashToG1(msg)
+	for i, pubKey := range pubKeys {
+		if paired, ok := curve.Pair(h, pubKey); ok && paired.Equals(target) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// VerifyTwoSignatures checks two standard BLS signatures, s1 on m1 by k1 and
+// s2 on m2 by k2, and reports each result. The two checks are first batched into
+// a single product of three pairings, instead of four, using random 128 bit
+// weights so that an invalid signature can't cancel out the other. Only if the
+// batched check fails are the signatures verified individually, to determine
+// which of them is invalid.
+func VerifyTwoSignatures(curve CurveSystem, k1, k2 Point, m1, m2 []byte, s1, s2 Point) (bool, bool) {
+	if CheckCurve(curve, k1, k2, s1, s2) != nil {
+		return false, false
+	}
+	r1, err1 := rand.Int(rand.Reader, batchWeightBound)
+	r2, err2 := rand.Int(rand.Reader, batchWeightBound)
+	if err1 == nil && err2 == nil {
+		// Ensure that neither weight is zero
+		r1.Add(r1, big.NewInt(1))
+		r2.Add(r2, big.NewInt(1))
+		weightedSig, _ := s1.Mul(r1).Add(s2.Mul(r2))
+		h1 := curve.HashToG1(m1).Mul(r1)
+		h2 := curve.HashToG1(m2).Mul(r2)
+		paired, ok := curve.PairingProduct([]Point{h1, h2, weightedSig.Neg()}, []Point{k1, k2, curve.GetG2()})
+		if ok && paired.Equals(curve.GetGTIdentity()) {
+			return true, true
+		}
+	}
+	return VerifySingleSignature(curve, s1, k1, m1), VerifySingleSignature(curve, s2, k2, m2)
+}
+
 // Verify verifies an aggregate signature type.
 func (a *AggSig) Verify(curve CurveSystem) bool {
 	return VerifyAggregateSignature(curve, a.sig, a.keys, a.msgs)
@@ -79,42 +386,310 @@ func (a *AggSig) Verify(curve CurveSystem) bool {
 // If duplicate messages should be allowed, one of the protections against the
 // rogue public-key attack should be used. See doc.go for more details.
 func VerifyAggregateSignature(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	requireCurve(curve, "VerifyAggregateSignature")
+	return verifyAggSig(curve, aggsig, keys, msgs, false)
+}
+
+// VerifyAggregateSignatureNoDupCheck verifies an aggregate signature as
+// VerifyAggregateSignature does, but skips the scan for duplicate messages.
+// This is only secure if the caller has already guaranteed that all of the
+// messages are distinct, e.g. if each message contains a unique index. With
+// duplicate messages, this is vulnerable to the rogue public-key attack.
+func VerifyAggregateSignatureNoDupCheck(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	requireCurve(curve, "VerifyAggregateSignatureNoDupCheck")
+	return verifyAggSig(curve, aggsig, keys, msgs, true)
+}
+
+// VerifyAggregateSignatureAllowDuplicates verifies an aggregate signature as
+// VerifyAggregateSignature does, but accepts duplicate messages.
+//
+// WARNING: with duplicate messages, plain BLS is broken by the rogue public key
+// attack, where an attacker chooses their key so that the aggregate appears to be
+// signed by keys that never signed. Only use this if every key has been protected
+// by a rogue key defense, e.g. its authentication (proof of possession) was
+// checked with CheckAuthentication when it was registered, or the messages were
+// augmented with the key as in blsDistinctMessage.go. This is
+// VerifyAggregateSignatureNoDupCheck under the name for that use.
+func VerifyAggregateSignatureAllowDuplicates(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	return VerifyAggregateSignatureNoDupCheck(curve, aggsig, keys, msgs)
+}
+
+// VerifyAggregateSignaturePaired verifies an aggregate signature as
+// VerifyAggregateSignature does, with each key given together with the message it
+// signed, so the keys and messages can't be misaligned. The order of the pairs
+// doesn't affect the result.
+func VerifyAggregateSignaturePaired(curve CurveSystem, aggsig Point, pairs []AggSigComponent) bool {
+	requireCurve(curve, "VerifyAggregateSignaturePaired")
+	keys := make([]Point, len(pairs))
+	msgs := make([][]byte, len(pairs))
+	for i := 0; i < len(pairs); i++ {
+		keys[i], msgs[i] = pairs[i].Key, pairs[i].Msg
+	}
 	return verifyAggSig(curve, aggsig, keys, msgs, false)
 }
 
+// VerifyAggregateSignatureIndexed verifies an aggregate signature where the
+// messages are given as indices into a shared table of messages, i.e. keys[i]
+// signed table[indices[i]]. This fails if any index is out of range, and as with
+// VerifyAggregateSignature, if any message is referenced twice.
+func VerifyAggregateSignatureIndexed(curve CurveSystem, aggsig Point, keys []Point,
+	table [][]byte, indices []int) bool {
+	if len(keys) != len(indices) {
+		return false
+	}
+	msgs := make([][]byte, len(indices))
+	for i := 0; i < len(indices); i++ {
+		if indices[i] < 0 || indices[i] >= len(table) {
+			return false
+		}
+		msgs[i] = table[indices[i]]
+	}
+	return verifyAggSig(curve, aggsig, keys, msgs, false)
+}
+
+// VerifyTwoMessageAggregate verifies an aggregate signature where keysA all
+// signed msgA, and keysB all signed msgB. The keys for each message are aggregated
+// first, so this only requires three pairings regardless of the number of signers.
+// Either group of keys may be empty, but not both. As with multi signatures, this
+// is vulnerable to the rogue public key attack, so the keys must have been
+// protected by one of the defense mechanisms. See doc.go for more details.
+func VerifyTwoMessageAggregate(curve CurveSystem, aggsig Point, keysA []Point, msgA []byte,
+	keysB []Point, msgB []byte) bool {
+	if degenerateAggregate(curve, aggsig, len(keysA)+len(keysB)) {
+		return false
+	}
+	if len(keysA) != 0 && len(keysB) != 0 && bytes.Equal(msgA, msgB) {
+		return false
+	}
+	if CheckCurve(curve, aggsig) != nil || CheckCurve(curve, keysA...) != nil ||
+		CheckCurve(curve, keysB...) != nil {
+		return false
+	}
+	pts1 := make([]Point, 0, 3)
+	pts2 := make([]Point, 0, 3)
+	if len(keysA) != 0 {
+		pts1 = append(pts1, curve.HashToG1(msgA))
+		pts2 = append(pts2, AggregatePoints(keysA))
+	}
+	if len(keysB) != 0 {
+		pts1 = append(pts1, curve.HashToG1(msgB))
+		pts2 = append(pts2, AggregatePoints(keysB))
+	}
+	pts1 = append(pts1, aggsig.Neg())
+	pts2 = append(pts2, curve.GetG2())
+	aggPt, ok := curve.PairingProduct(pts1, pts2)
+	if ok {
+		return aggPt.Equals(curve.GetGTIdentity())
+	}
+	return ok
+}
+
+// degenerateAggregate reports whether an aggregate signature is nil or the
+// identity, or aggregates no keys. The pairing check alone accepts these, e.g. an
+// identity signature passes for an empty set of keys, or for identity keys.
+func degenerateAggregate(curve CurveSystem, aggsig Point, numKeys int) bool {
+	return numKeys == 0 || aggsig == nil || aggsig.Equals(curve.GetG1Infinity())
+}
+
 // verifyMultiSignature checks that the aggregate signature correctly proves
 // that a single message has been signed by a set of keys. This is
 // vulnerable to the rogue public attack, so one of the defense mechanisms should be used.
 func verifyMultiSignature(curve CurveSystem, aggsig Point, keys []Point, msg []byte) bool {
+	if degenerateAggregate(curve, aggsig, len(keys)) {
+		return false
+	}
 	vs := AggregatePoints(keys)
 	return VerifySingleSignature(curve, aggsig, vs, msg)
 }
 
 func verifyAggSig(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, allowDuplicates bool) bool {
-	if len(keys) != len(msgs) {
+	return verifyAggSigCustHash(curve, aggsig, keys, msgs, allowDuplicates, curve.HashToG1)
+}
+
+// verifyAggSigCustHash verifies an aggregate signature as verifyAggSig does,
+// using a supplied function to hash the messages onto G1.
+func verifyAggSigCustHash(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	allowDuplicates bool, hash func([]byte) Point) bool {
+	pts1, pts2, ok := aggSigPairingInputs(curve, aggsig, keys, msgs, allowDuplicates, hash)
+	if !ok {
 		return false
 	}
+	aggPt, ok := curve.PairingProduct(pts1, pts2)
+	if ok {
+		return aggPt.Equals(curve.GetGTIdentity())
+	}
+	return ok
+}
+
+// aggSigPairingInputs checks an aggregate signature's keys and messages, and
+// returns the points whose pairing product is the identity if it's valid.
+func aggSigPairingInputs(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	allowDuplicates bool, hash func([]byte) Point) ([]Point, []Point, bool) {
+	if len(keys) != len(msgs) || degenerateAggregate(curve, aggsig, len(keys)) {
+		return nil, nil, false
+	}
+	if CheckCurve(curve, aggsig) != nil || CheckCurve(curve, keys...) != nil {
+		return nil, nil, false
+	}
 	if !allowDuplicates {
 		if containsDuplicateMessage(msgs) {
-			return false
+			return nil, nil, false
 		}
 	}
-	pts1 := make([]Point, len(keys)+1)
+	pts1 := append(HashToG1BatchCustHash(msgs, hash), nil)
 	pts2 := make([]Point, len(keys)+1)
+	copy(pts2, keys)
+	pts1[len(keys)] = aggsig.Mul(new(big.Int).SetInt64(-1))
+	pts2[len(keys)] = curve.GetG2()
+	return pts1, pts2, true
+}
+
+// ComputeAggregatePairingTarget returns the product of the pairings of each
+// message's hash with its key, which is the pairing of a valid aggregate signature
+// with the G2 generator. This is nil if the keys and messages differ in number,
+// or the keys aren't on the curve. It doesn't check for duplicate messages, so is
+// only as secure as the rogue public key defence of the keys.
+func ComputeAggregatePairingTarget(curve CurveSystem, keys []Point, msgs [][]byte) PointT {
+	requireCurve(curve, "ComputeAggregatePairingTarget")
+	if len(keys) != len(msgs) || CheckCurve(curve, keys...) != nil {
+		return nil
+	}
+	target, ok := curve.PairingProduct(HashToG1Batch(curve, msgs), keys)
+	if !ok {
+		return nil
+	}
+	return target
+}
+
+// ErrPairingTimeout is returned when a single pairing takes longer than the
+// verifier's timeout.
+var ErrPairingTimeout = errors.New("pairing exceeded the timeout")
+
+// VerifyAggregateSignatureWithTimeout verifies an aggregate signature as
+// VerifyAggregateSignature does, but bounds the time of each pairing. The
+// pairings are computed one after another, and if any takes longer than
+// pairingTimeout, verification stops and ErrPairingTimeout is returned. The
+// timed out pairing is left to finish in the background, and its result is
+// discarded. This takes up to len(keys)+1 times pairingTimeout in total.
+func VerifyAggregateSignatureWithTimeout(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	pairingTimeout time.Duration) (bool, error) {
+	requireCurve(curve, "VerifyAggregateSignatureWithTimeout")
+	pts1, pts2, ok := aggSigPairingInputs(curve, aggsig, keys, msgs, false, curve.HashToG1)
+	if !ok {
+		return false, nil
+	}
+	product := curve.GetGTIdentity()
+	for i := 0; i < len(pts1); i++ {
+		// Buffered, so that a timed out pairing doesn't block forever on sending.
+		c := make(chan PointT, 1)
+		go concurrentPair(curve, pts1[i], pts2[i], c)
+		timer := time.NewTimer(pairingTimeout)
+		select {
+		case paired := <-c:
+			timer.Stop()
+			if paired == nil {
+				return false, nil
+			}
+			if product, ok = product.Add(paired); !ok {
+				return false, nil
+			}
+		case <-timer.C:
+			return false, ErrPairingTimeout
+		}
+	}
+	return product.Equals(curve.GetGTIdentity()), nil
+}
+
+// concurrentPair sends the pairing of the two points on the channel, or nil if
+// they can't be paired.
+func concurrentPair(curve CurveSystem, pt1 Point, pt2 Point, c chan PointT) {
+	if paired, ok := curve.Pair(pt1, pt2); ok {
+		c <- paired
+		return
+	}
+	c <- nil
+}
+
+// AggregateValid verifies each signature against its key and message, and
+// aggregates only the valid signatures. It returns the aggregate signature and
+// the indices of the valid signatures, so the aggregate can be verified against
+// the corresponding keys and messages. If there are no valid signatures, the
+// aggregate signature is nil.
+func AggregateValid(curve CurveSystem, keys []Point, msgs [][]byte, sigs []Point) (Point, []int) {
+	if len(keys) != len(msgs) || len(keys) != len(sigs) {
+		return nil, []int{}
+	}
+	valid := verifySignatures(curve, keys, msgs, sigs)
+	validIndices := make([]int, 0, len(sigs))
+	validSigs := make([]Point, 0, len(sigs))
+	for i := 0; i < len(sigs); i++ {
+		if valid[i] {
+			validIndices = append(validIndices, i)
+			validSigs = append(validSigs, sigs[i])
+		}
+	}
+	if len(validSigs) == 0 {
+		return nil, validIndices
+	}
+	return AggregatePoints(validSigs), validIndices
+}
+
+// VerifyAggregateLocating verifies an aggregate signature as
+// VerifyAggregateSignature does, and if it is invalid, locates the signers
+// responsible. An aggregate alone can't attribute a bad contribution, so this
+// also takes each signer's signature, where sigs[i] is the signature on msgs[i]
+// by keys[i], and returns the indices of the signatures which are invalid. If
+// the aggregate is invalid although every signature is valid, e.g. because
+// aggsig isn't their sum, no indices are returned. The indices are nil if the
+// keys, messages and signatures differ in number.
+func VerifyAggregateLocating(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	sigs []Point) (bool, []int) {
+	if len(keys) != len(msgs) || len(keys) != len(sigs) {
+		return false, nil
+	}
+	if VerifyAggregateSignature(curve, aggsig, keys, msgs) {
+		return true, []int{}
+	}
+	valid := verifySignatures(curve, keys, msgs, sigs)
+	invalid := make([]int, 0)
+	for i := 0; i < len(sigs); i++ {
+		if !valid[i] {
+			invalid = append(invalid, i)
+		}
+	}
+	return false, invalid
+}
+
+// verifySignatures verifies each signature sigs[i] on msgs[i] by keys[i], across
+// a pool of workers as in signBatch, and returns whether each is valid.
+func verifySignatures(curve CurveSystem, keys []Point, msgs [][]byte, sigs []Point) []bool {
+	valid := make([]bool, len(sigs))
+	workers := runtime.NumCPU()
+	if workers > len(sigs) {
+		workers = len(sigs)
+	}
+	indices := make(chan int, len(sigs))
+	for i := 0; i < len(sigs); i++ {
+		indices <- i
+	}
+	close(indices)
 	var wg sync.WaitGroup
-	wg.Add(len(msgs))
-	for i := 0; i < len(msgs); i++ {
-		go concurrentHash(curve, i, pts1, msgs[i], &wg)
-		pts2[i] = keys[i]
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go concurrentVerifySignatures(curve, keys, msgs, sigs, valid, indices, &wg)
 	}
 	wg.Wait()
-	pts1[len(keys)] = aggsig.Mul(new(big.Int).SetInt64(-1))
-	pts2[len(keys)] = curve.GetG2()
-	aggPt, ok := curve.PairingProduct(pts1, pts2)
-	if ok {
-		return aggPt.Equals(curve.GetGTIdentity())
+	return valid
+}
+
+// concurrentVerifySignatures verifies the signatures whose indices are received
+// on the channel, and stores the results in valid.
+func concurrentVerifySignatures(curve CurveSystem, keys []Point, msgs [][]byte, sigs []Point,
+	valid []bool, indices chan int, wg *sync.WaitGroup) {
+	for i := range indices {
+		valid[i] = sigs[i] != nil && keys[i] != nil && VerifySingleSignature(curve, sigs[i], keys[i], msgs[i])
 	}
-	return ok
+	wg.Done()
 }
 
 // AggregateSignatures aggregates an array of signatures into one aggsig.
@@ -123,16 +698,71 @@ func AggregateSignatures(sigs []Point) Point {
 	return AggregatePoints(sigs)
 }
 
+// ErrTooManySignatures is returned by AggregateSignaturesCapped when there are
+// more signatures than the cap.
+var ErrTooManySignatures = errors.New("too many signatures to aggregate")
+
+// AggregateSignaturesCapped aggregates the signatures as AggregateSignatures
+// does, but returns ErrTooManySignatures without aggregating anything if there
+// are more than max signatures. It returns ErrCurveMismatch if any signature
+// doesn't belong to curve.
+func AggregateSignaturesCapped(curve CurveSystem, sigs []Point, max int) (Point, error) {
+	if len(sigs) > max {
+		return nil, ErrTooManySignatures
+	}
+	if err := CheckCurve(curve, sigs...); err != nil {
+		return nil, err
+	}
+	return SafeAggregatePoints(sigs)
+}
+
 // AggregateKeys sums an array of public keys into one key.
 // This wrapper only exists so end-users don't have to use the method from curve
 func AggregateKeys(keys []Point) Point {
 	return AggregatePoints(keys)
 }
 
-// concurrentHash hashes the message and sends the result down the channel.
-func concurrentHash(curve CurveSystem, i int, pts []Point, msg []byte, wg *sync.WaitGroup) {
-	pts[i] = curve.HashToG1(msg)
-	wg.Done()
+// UpdateAggregateKey patches an aggregate public key after a change in the set
+// of signers, by subtracting the removed keys and adding the added keys.
+// This avoids re-aggregating every key in the new set.
+func UpdateAggregateKey(apk Point, removed []Point, added []Point) Point {
+	pts := make([]Point, 0, 1+len(removed)+len(added))
+	pts = append(pts, apk)
+	for i := 0; i < len(removed); i++ {
+		pts = append(pts, removed[i].Neg())
+	}
+	pts = append(pts, added...)
+	return AggregatePoints(pts)
+}
+
+// SubtractSignature removes a signer's signature from an aggregate signature, by
+// adding its negation. The result is only meaningful if sig was included in the
+// aggregate, in which case it verifies against the remaining signers.
+func SubtractSignature(aggsig Point, sig Point) Point {
+	diff, _ := aggsig.Add(sig.Neg())
+	return diff
+}
+
+// SubtractKey removes a signer's key from an aggregate public key, as
+// SubtractSignature does for signatures.
+func SubtractKey(apk Point, key Point) Point {
+	diff, _ := apk.Add(key.Neg())
+	return diff
+}
+
+// ComplementSignature returns the aggregate signature of the signers missing
+// from a partial aggregate, given the full aggregate of every signer, e.g. to
+// account for a committee's non signers. It is full minus partial, so it
+// verifies against the keys of the signers in full but not in partial.
+func ComplementSignature(full Point, partial Point) Point {
+	return SubtractSignature(full, partial)
+}
+
+// ComplementKeys returns the aggregate public key of the signers missing from a
+// partial aggregate key, given the full aggregate key, as ComplementSignature
+// does for signatures.
+func ComplementKeys(full Point, partial Point) Point {
+	return SubtractKey(full, partial)
 }
 
 func containsDuplicateMessage(msgs [][]byte) bool {
@@ -5,10 +5,13 @@ package bgls
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"math/big"
 	"os"
 	"testing"
+	"time"
 
+	gosha3 "github.com/ethereum/go-ethereum/crypto/sha3"
 	. "github.com/orbs-network/bgls/curves"
 	"github.com/stretchr/testify/assert"
 )
@@ -37,6 +40,99 @@ func TestSingleSigner(t *testing.T) {
 	}
 }
 
+func TestCheckPairingMatchesVerification(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		sig := Sign(curve, sk, msg)
+		target, _ := curve.Pair(curve.HashToG1(msg), vk)
+		assert.Equal(t, VerifySingleSignature(curve, sig, vk, msg),
+			CheckPairingEquals(curve, sig, curve.GetG2(), target))
+		assert.True(t, CheckPairingEquals(curve, sig, curve.GetG2(), target))
+		sig2, _ := sig.Add(curve.GetG1())
+		assert.Equal(t, VerifySingleSignature(curve, sig2, vk, msg),
+			CheckPairingEquals(curve, sig2, curve.GetG2(), target))
+		assert.False(t, CheckPairingEquals(curve, sig2, curve.GetG2(), target))
+	}
+}
+
+func TestSignWithCustomInnerHash(t *testing.T) {
+	for _, curve := range curves {
+		dst := []byte("BGLS_TEST_DST")
+		shaHash := func(msg []byte) Point {
+			return curve.HashToG1WithHash(msg, dst, sha256.New)
+		}
+		keccakHash := func(msg []byte) Point {
+			return curve.HashToG1WithHash(msg, dst, gosha3.NewKeccak256)
+		}
+		sk, vk, _ := KeyGen(curve)
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		shaSig := SignCustHash(sk, msg, shaHash)
+		keccakSig := SignCustHash(sk, msg, keccakHash)
+		assert.False(t, shaSig.Equals(keccakSig), "Signatures with differing hashes are equal")
+		assert.True(t, VerifySingleSignatureCustHash(curve, shaSig, vk, msg, shaHash),
+			"SHA256 signature verification failed")
+		assert.True(t, VerifySingleSignatureCustHash(curve, keccakSig, vk, msg, keccakHash),
+			"Keccak256 signature verification failed")
+		assert.False(t, VerifySingleSignatureCustHash(curve, shaSig, vk, msg, keccakHash),
+			"SHA256 signature verified with the Keccak256 hash")
+	}
+}
+
+func TestPublicKeyMatches(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		sk2, vk2, _ := KeyGen(curve)
+		assert.True(t, PublicKeyMatches(curve, sk, vk), "Matching key pair rejected")
+		assert.True(t, PublicKeyMatches(curve, sk2, vk2), "Matching key pair rejected")
+		assert.False(t, PublicKeyMatches(curve, sk, vk2), "Mismatched key pair accepted")
+		assert.False(t, PublicKeyMatches(curve, sk, curve.GetG1().Mul(sk)),
+			"Key pair accepted with the public key in the wrong group")
+		assert.False(t, PublicKeyMatches(curve, sk, nil), "Nil public key accepted")
+	}
+}
+
+func TestNilCurve(t *testing.T) {
+	_, _, err := KeyGen(nil)
+	assert.Equal(t, ErrNilCurve, err)
+	sk, vk, _ := KeyGen(Altbn128)
+	msg := []byte("message")
+	sig := Sign(Altbn128, sk, msg)
+	assert.PanicsWithValue(t, "bgls: Sign called with a nil curve", func() { Sign(nil, sk, msg) })
+	assert.PanicsWithValue(t, "bgls: VerifySingleSignature called with a nil curve",
+		func() { VerifySingleSignature(nil, sig, vk, msg) })
+	assert.PanicsWithValue(t, "bgls: LoadPublicKey called with a nil curve", func() { LoadPublicKey(nil, sk) })
+	assert.PanicsWithValue(t, "bgls: VerifyAggregateSignature called with a nil curve",
+		func() { VerifyAggregateSignature(nil, sig, []Point{vk}, [][]byte{msg}) })
+	assert.PanicsWithValue(t, "bgls: VerifyAggregateSignatureNoDupCheck called with a nil curve",
+		func() { VerifyAggregateSignatureNoDupCheck(nil, sig, []Point{vk}, [][]byte{msg}) })
+	assert.PanicsWithValue(t, "bgls: KoskVerifyMultiSignature called with a nil curve",
+		func() { KoskVerifyMultiSignature(nil, sig, []Point{vk}, msg) })
+}
+
+func TestSecretKeyEqual(t *testing.T) {
+	for _, curve := range curves {
+		sk, _, _ := KeyGen(curve)
+		other, _, _ := KeyGen(curve)
+		small := big.NewInt(5)
+		keys := []*big.Int{sk, other, small, big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), 255)}
+		for _, a := range keys {
+			for _, b := range keys {
+				assert.Equal(t, a.Cmp(b) == 0, SecretKeyEqual(a, new(big.Int).Set(b)),
+					"SecretKeyEqual doesn't match Cmp for "+a.String()+" and "+b.String())
+			}
+		}
+		// A leading zero byte in the encoding doesn't change the key.
+		assert.True(t, SecretKeyEqual(small, new(big.Int).SetBytes([]byte{0, 0, 5})))
+		assert.False(t, SecretKeyEqual(big.NewInt(-5), small), "A negative key equals a positive key")
+		assert.False(t, SecretKeyEqual(new(big.Int).Lsh(big.NewInt(1), 256), new(big.Int).Lsh(big.NewInt(1), 256)),
+			"A key wider than 32 bytes was compared")
+		assert.False(t, SecretKeyEqual(nil, small))
+	}
+}
+
 func TestAggregation(t *testing.T) {
 	for _, curve := range curves {
 		N, Size := 6, 32
@@ -76,6 +172,720 @@ func TestAggregation(t *testing.T) {
 	}
 }
 
+func TestAggSigComponents(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		a, _ := NewAggSig(keys, msgs, AggregateSignatures(sigs))
+		components := a.Components()
+		assert.Equal(t, N, len(components))
+		for i := 0; i < N; i++ {
+			assert.True(t, components[i].Key.Equals(keys[i]), "Component key doesn't match")
+			assert.Equal(t, msgs[i], components[i].Msg, "Component message doesn't match")
+			// Spot check that a lone contribution is valid
+			assert.True(t, VerifySingleSignature(curve, sigs[i], components[i].Key, components[i].Msg))
+		}
+		assert.True(t, a.Signature().Equals(AggregateSignatures(sigs)))
+		sum := keys[0]
+		for i := 1; i < N; i++ {
+			sum, _ = sum.Add(keys[i])
+		}
+		assert.True(t, a.AggregateKey().Equals(sum), "AggregateKey differs from the sum of the keys")
+		components[0].Msg[0]++
+		assert.True(t, a.Verify(curve), "Modifying a component modified the AggSig")
+	}
+}
+
+// outsideSubgroupPoint is a point which claims not to be in its subgroup.
+type outsideSubgroupPoint struct {
+	Point
+}
+
+func (p outsideSubgroupPoint) InCorrectSubgroup() bool {
+	return false
+}
+
+func TestAggSigValidate(t *testing.T) {
+	for _, curve := range curves {
+		N := 3
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			msgs[i] = []byte{byte(i)}
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		aggsig := AggregateSignatures(sigs)
+		a, _ := NewAggSig(keys, msgs, aggsig)
+		assert.Nil(t, a.Validate(), "Valid aggregate signature failed validation")
+
+		a = &AggSig{keys: keys, msgs: msgs[1:], sig: aggsig}
+		assert.Equal(t, ErrLengthMismatch, a.Validate())
+		a = &AggSig{keys: keys, msgs: msgs}
+		assert.Equal(t, ErrNilPoint, a.Validate())
+		a, _ = NewAggSig([]Point{keys[0], nil, keys[2]}, msgs, aggsig)
+		assert.Equal(t, ErrNilPoint, a.Validate())
+		a, _ = NewAggSig(keys, msgs, outsideSubgroupPoint{aggsig})
+		assert.Equal(t, ErrNotInSubgroup, a.Validate())
+		a, _ = NewAggSig([]Point{keys[0], keys[1], outsideSubgroupPoint{keys[2]}}, msgs, aggsig)
+		assert.Equal(t, ErrNotInSubgroup, a.Validate())
+		a, _ = NewAggSig(keys, msgs, curve.GetG1Infinity())
+		assert.Equal(t, ErrDegenerateAggregate, a.Validate())
+		a, _ = NewAggSig([]Point{}, [][]byte{}, aggsig)
+		assert.Equal(t, ErrDegenerateAggregate, a.Validate())
+		a, _ = NewAggSig(keys, [][]byte{msgs[0], msgs[1], msgs[0]}, aggsig)
+		assert.Equal(t, ErrDuplicateMessage, a.Validate())
+	}
+}
+
+func TestMultiSigAccessors(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		keys := make([]Point, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			sigs[i] = KoskSign(curve, sk, msg)
+		}
+		m, _ := NewMultiSig(keys, AggregateSignatures(sigs), msg)
+		sig := m.Sig()
+		assert.True(t, KoskVerifySingleSignature(curve, sig, AggregatePoints(m.Keys()), msg),
+			"MultiSig signature doesn't verify against its aggregate key")
+
+		// Fold the multi signature into a larger one
+		sk, vk, _ := KeyGen(curve)
+		folded, _ := sig.Add(KoskSign(curve, sk, msg))
+		assert.True(t, KoskVerifyMultiSignature(curve, folded, append(m.Keys(), vk), msg),
+			"Folded multi signature failed verification")
+
+		m.Keys()[0] = vk
+		assert.True(t, m.Verify(curve), "Modifying the keys modified the MultiSig")
+	}
+}
+
+// otherCurve is a copy of a curve system, which counts as a distinct curve.
+type otherCurve struct {
+	CurveSystem
+}
+
+func (c *otherCurve) Name() string {
+	return "other " + c.CurveSystem.Name()
+}
+
+// otherCurvePoint is a point which claims to belong to another curve.
+type otherCurvePoint struct {
+	Point
+	curve CurveSystem
+}
+
+func (p otherCurvePoint) Curve() CurveSystem {
+	return p.curve
+}
+
+func TestCurveMismatch(t *testing.T) {
+	for _, curve := range curves {
+		other := &otherCurve{curve}
+		N := 3
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateSignature(curve, aggsig, keys, msgs))
+		assert.True(t, VerifySingleSignature(curve, sigs[0], keys[0], msgs[0]))
+
+		foreignKeys := append([]Point{}, keys...)
+		foreignKeys[1] = otherCurvePoint{keys[1], other}
+		assert.False(t, VerifyAggregateSignature(curve, aggsig, foreignKeys, msgs),
+			"Verified an aggregate signature with a key from another curve")
+		assert.False(t, VerifySingleSignature(curve, sigs[1], foreignKeys[1], msgs[1]),
+			"Verified a signature with a key from another curve")
+		assert.False(t, VerifySingleSignature(curve, otherCurvePoint{sigs[0], other}, keys[0], msgs[0]),
+			"Verified a signature from another curve")
+		assert.False(t, VerifyTwoMessageAggregate(curve, aggsig, foreignKeys[:2], msgs[0], keys[2:], msgs[2]),
+			"Verified a two message aggregate with a key from another curve")
+		_, err := SafeAggregatePoints(foreignKeys)
+		assert.Equal(t, ErrCurveMismatch, err)
+	}
+}
+
+func TestVerifyAggregateSignatureNoDupCheck(t *testing.T) {
+	for _, curve := range curves {
+		N := 5
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = []byte{byte(i)}
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateSignatureNoDupCheck(curve, aggsig, keys, msgs),
+			"Aggregate signature verification without the duplicate check failed")
+		assert.False(t, VerifyAggregateSignatureNoDupCheck(curve, aggsig, keys[1:], msgs[1:]),
+			"Aggregate signature verification without the duplicate check succeeded with a missing signer")
+		msgs[0] = []byte{byte(N)}
+		assert.False(t, VerifyAggregateSignatureNoDupCheck(curve, aggsig, keys, msgs),
+			"Aggregate signature verification without the duplicate check succeeded on the wrong message")
+	}
+}
+
+func TestVerifyTwoSignatures(t *testing.T) {
+	for _, curve := range curves {
+		sk1, k1, _ := KeyGen(curve)
+		sk2, k2, _ := KeyGen(curve)
+		m1, m2 := make([]byte, 32), make([]byte, 32)
+		rand.Read(m1)
+		rand.Read(m2)
+		s1, s2 := Sign(curve, sk1, m1), Sign(curve, sk2, m2)
+		counter := &pairingCounter{CurveSystem: curve}
+		ok1, ok2 := VerifyTwoSignatures(counter, k1, k2, m1, m2, s1, s2)
+		assert.True(t, ok1 && ok2, "Verifying two valid signatures failed")
+		assert.Equal(t, 3, counter.pairings, "Two valid signatures weren't batched")
+
+		bad, _ := s2.Add(curve.GetG1())
+		ok1, ok2 = VerifyTwoSignatures(curve, k1, k2, m1, m2, s1, bad)
+		assert.True(t, ok1, "The valid signature failed verification")
+		assert.False(t, ok2, "The invalid signature passed verification")
+		ok1, ok2 = VerifyTwoSignatures(curve, k1, k2, m1, m2, bad, s2)
+		assert.False(t, ok1, "The invalid signature passed verification")
+		assert.True(t, ok2, "The valid signature failed verification")
+		ok1, ok2 = VerifyTwoSignatures(curve, k1, k2, m1, m2, s2, s1)
+		assert.False(t, ok1 || ok2, "Swapped signatures passed verification")
+
+		// Two invalid signatures whose errors cancel out in the unweighted sum.
+		shifted1, _ := s1.Add(curve.GetG1())
+		shifted2, _ := s1.Add(curve.GetG1().Neg())
+		ok1, ok2 = VerifyTwoSignatures(curve, k1, k1, m1, m1, shifted1, shifted2)
+		assert.False(t, ok1 || ok2, "Cancelling invalid signatures passed verification")
+	}
+}
+
+func TestAggregateSignaturesCapped(t *testing.T) {
+	for _, curve := range curves {
+		max := 4
+		sigs := make([]Point, max+1)
+		for i := 0; i < len(sigs); i++ {
+			sk, _, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, []byte("message"))
+		}
+		agg, err := AggregateSignaturesCapped(curve, sigs[:max], max)
+		assert.Nil(t, err, "Aggregating max signatures failed")
+		assert.True(t, agg.Equals(AggregateSignatures(sigs[:max])))
+		_, err = AggregateSignaturesCapped(curve, sigs, max)
+		assert.Equal(t, ErrTooManySignatures, err, "Aggregated more than max signatures")
+		_, err = AggregateSignaturesCapped(curve, []Point{sigs[0], otherCurvePoint{sigs[1], &otherCurve{curve}}}, max)
+		assert.Equal(t, ErrCurveMismatch, err)
+	}
+}
+
+func TestVerifyAggregateSignatureAllowDuplicates(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			assert.True(t, CheckAuthentication(curve, vk, Authenticate(curve, sk)))
+			keys[i] = vk
+			msgs[i] = msg
+			sigs[i] = Sign(curve, sk, msg)
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateSignatureAllowDuplicates(curve, aggsig, keys, msgs),
+			"Aggregate signature with duplicate messages failed verification")
+		assert.False(t, VerifyAggregateSignature(curve, aggsig, keys, msgs),
+			"Standard verification accepted duplicate messages")
+		assert.False(t, VerifyAggregateSignatureAllowDuplicates(curve, sigs[0], keys, msgs),
+			"Invalid aggregate signature with duplicate messages passed verification")
+	}
+}
+
+func TestVerifyAggregateSignaturePaired(t *testing.T) {
+	for _, curve := range curves {
+		N := 6
+		pairs := make([]AggSigComponent, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			msg := make([]byte, 32)
+			rand.Read(msg)
+			pairs[i] = AggSigComponent{Key: vk, Msg: msg}
+			sigs[i] = Sign(curve, sk, msg)
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateSignaturePaired(curve, aggsig, pairs), "Paired aggregate failed verification")
+		shuffled := make([]AggSigComponent, N)
+		for i, j := range []int{4, 1, 5, 0, 3, 2} {
+			shuffled[i] = pairs[j]
+		}
+		assert.True(t, VerifyAggregateSignaturePaired(curve, aggsig, shuffled),
+			"Shuffled paired aggregate failed verification")
+		shuffled[0].Key, shuffled[1].Key = shuffled[1].Key, shuffled[0].Key
+		assert.False(t, VerifyAggregateSignaturePaired(curve, aggsig, shuffled),
+			"Paired aggregate with swapped keys passed verification")
+		assert.False(t, VerifyAggregateSignaturePaired(curve, aggsig, pairs[1:]),
+			"Paired aggregate with a missing pair passed verification")
+	}
+}
+
+func TestComputeAggregatePairingTarget(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		target := ComputeAggregatePairingTarget(curve, keys, msgs)
+		paired, _ := curve.Pair(AggregateSignatures(sigs), curve.GetG2())
+		assert.True(t, paired.Equals(target), "Aggregate signature doesn't pair to the target")
+		paired, _ = curve.Pair(AggregateSignatures(sigs[1:]), curve.GetG2())
+		assert.False(t, paired.Equals(target), "Partial aggregate signature pairs to the target")
+		assert.Nil(t, ComputeAggregatePairingTarget(curve, keys, msgs[1:]))
+	}
+}
+
+// slowCurve delays every pairing of the curve.
+type slowCurve struct {
+	CurveSystem
+	delay time.Duration
+}
+
+func (c *slowCurve) Pair(pt1 Point, pt2 Point) (PointT, bool) {
+	time.Sleep(c.delay)
+	return c.CurveSystem.Pair(pt1, pt2)
+}
+
+func TestVerifyAggregateSignatureWithTimeout(t *testing.T) {
+	for _, curve := range curves {
+		N := 3
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		aggsig := AggregateSignatures(sigs)
+		ok, err := VerifyAggregateSignatureWithTimeout(curve, aggsig, keys, msgs, time.Second)
+		assert.True(t, ok, "Aggregate signature failed verification with a timeout")
+		assert.Nil(t, err)
+		ok, err = VerifyAggregateSignatureWithTimeout(curve, sigs[0], keys, msgs, time.Second)
+		assert.False(t, ok, "Invalid aggregate signature passed verification with a timeout")
+		assert.Nil(t, err)
+
+		slow := &slowCurve{CurveSystem: curve, delay: 200 * time.Millisecond}
+		ok, err = VerifyAggregateSignatureWithTimeout(slow, aggsig, keys, msgs, 50*time.Millisecond)
+		assert.False(t, ok, "Slow pairing passed verification")
+		assert.Equal(t, ErrPairingTimeout, err, "Slow pairing didn't time out")
+	}
+}
+
+func TestDegenerateAggregate(t *testing.T) {
+	for _, curve := range curves {
+		identitySig := curve.GetG1Infinity()
+		identityKey := curve.GetG2Infinity()
+		msg := []byte("message")
+		assert.False(t, VerifyAggregateSignature(curve, identitySig, []Point{}, [][]byte{}),
+			"Identity aggregate of no keys passed verification")
+		assert.False(t, VerifyAggregateSignature(curve, identitySig, []Point{identityKey}, [][]byte{msg}),
+			"Identity aggregate of identity keys passed verification")
+		assert.False(t, VerifyAggregateSignatureNoDupCheck(curve, identitySig, []Point{identityKey}, [][]byte{msg}),
+			"Identity aggregate of identity keys passed verification")
+		assert.False(t, KoskVerifyMultiSignature(curve, identitySig, []Point{identityKey}, msg),
+			"Identity multi signature of identity keys passed verification")
+		assert.False(t, KoskVerifyMultiSignature(curve, identitySig, []Point{}, msg),
+			"Identity multi signature of no keys passed verification")
+		assert.False(t, VerifyTwoMessageAggregate(curve, identitySig, []Point{identityKey}, msg, nil, nil),
+			"Identity two message aggregate of identity keys passed verification")
+
+		// A key and its negation aggregate to the identity, so the identity
+		// aggregate signature passes the pairing check for them.
+		_, vk, _ := KeyGen(curve)
+		cancelling := []Point{vk, vk.Neg()}
+		assert.False(t, KoskVerifyMultiSignature(curve, identitySig, cancelling, msg),
+			"Identity multi signature of cancelling keys passed verification")
+	}
+}
+
+func TestDeriveAppKey(t *testing.T) {
+	for _, curve := range curves {
+		master, _, _ := KeyGen(curve)
+		skA, vkA, err := DeriveAppKey(curve, master, []byte("app A"))
+		assert.Nil(t, err)
+		skA2, vkA2, _ := DeriveAppKey(curve, master, []byte("app A"))
+		assert.True(t, SecretKeyEqual(skA, skA2) && vkA.Equals(vkA2), "App key derivation isn't deterministic")
+		skB, vkB, _ := DeriveAppKey(curve, master, []byte("app B"))
+		assert.False(t, SecretKeyEqual(skA, skB) || vkA.Equals(vkB), "App keys for different apps are equal")
+		assert.False(t, SecretKeyEqual(skA, master), "App key equals the master key")
+		other, _, _ := KeyGen(curve)
+		skOther, _, _ := DeriveAppKey(curve, other, []byte("app A"))
+		assert.False(t, SecretKeyEqual(skA, skOther), "App keys for different masters are equal")
+
+		msg := []byte("message")
+		assert.True(t, VerifySingleSignature(curve, Sign(curve, skA, msg), vkA, msg),
+			"Signature by an app key failed verification")
+		assert.True(t, skA.Sign() > 0 && skA.Cmp(curve.GetG1Order()) < 0, "App key isn't reduced")
+
+		_, _, err = DeriveAppKey(curve, big.NewInt(-1), []byte("app A"))
+		assert.Equal(t, ErrInvalidSecretKey, err)
+	}
+	_, _, err := DeriveAppKey(nil, big.NewInt(1), nil)
+	assert.Equal(t, ErrNilCurve, err)
+}
+
+func TestVerifyAgainstAny(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		sks := make([]*big.Int, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sks[i], keys[i], _ = KeyGen(curve)
+		}
+		msg := []byte("rotation")
+		for i := 0; i < N; i++ {
+			index, ok := VerifyAgainstAny(curve, keys, msg, Sign(curve, sks[i], msg))
+			assert.True(t, ok, "Signature by one of the keys wasn't found")
+			assert.Equal(t, i, index, "Wrong key was found")
+		}
+		_, other, _ := KeyGen(curve)
+		index, ok := VerifyAgainstAny(curve, []Point{other, keys[1], keys[1]}, msg, Sign(curve, sks[1], msg))
+		assert.True(t, ok)
+		assert.Equal(t, 1, index, "First matching key wasn't returned")
+		index, ok = VerifyAgainstAny(curve, keys, []byte("other"), Sign(curve, sks[0], msg))
+		assert.False(t, ok, "Signature on another message was found")
+		assert.Equal(t, -1, index)
+		_, ok = VerifyAgainstAny(curve, []Point{}, msg, Sign(curve, sks[0], msg))
+		assert.False(t, ok, "Signature was found without keys")
+	}
+}
+
+func TestSignAndAggregate(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 8, 32
+		sk, vk, _ := KeyGen(curve)
+		msgs := make([][]byte, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			keys[i] = vk
+		}
+		aggsig, err := SignAndAggregate(curve, sk, msgs)
+		assert.Nil(t, err)
+		assert.True(t, aggsig.Equals(AggregateSignatures(SignBatch(curve, sk, msgs))),
+			"SignAndAggregate differs from aggregating SignBatch")
+		assert.True(t, VerifyAggregateSignature(curve, aggsig, keys, msgs),
+			"SignAndAggregate's aggregate failed verification")
+		_, err = SignAndAggregate(curve, sk, [][]byte{})
+		assert.Equal(t, ErrDegenerateAggregate, err)
+	}
+	_, err := SignAndAggregate(nil, big.NewInt(1), [][]byte{{1}})
+	assert.Equal(t, ErrNilCurve, err)
+}
+
+func TestSignMany(t *testing.T) {
+	for _, curve := range curves {
+		N := 8
+		sks := make([]*big.Int, N)
+		vks := make([]Point, N)
+		msgs := make([][]byte, N)
+		for i := 0; i < N; i++ {
+			sks[i], vks[i], _ = KeyGen(curve)
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+		}
+		sigs, err := SignMany(curve, sks, msgs)
+		assert.Nil(t, err)
+		assert.Equal(t, N, len(sigs))
+		for i := 0; i < N; i++ {
+			assert.True(t, VerifySingleSignature(curve, sigs[i], vks[i], msgs[i]),
+				"Signature from SignMany failed verification")
+		}
+		_, err = SignMany(curve, sks, msgs[1:])
+		assert.Equal(t, ErrLengthMismatch, err, "Mismatched lengths didn't error")
+	}
+}
+
+func TestSignBatch(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 8, 32
+		sk, vk, _ := KeyGen(curve)
+		msgs := make([][]byte, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			keys[i] = vk
+		}
+		sigs := SignBatch(curve, sk, msgs)
+		assert.Equal(t, N, len(sigs))
+		for i := 0; i < N; i++ {
+			assert.True(t, sigs[i].Equals(Sign(curve, sk, msgs[i])),
+				"Batch signature differs from Sign")
+			assert.True(t, VerifySingleSignature(curve, sigs[i], vk, msgs[i]),
+				"Batch signature verification failed")
+		}
+		assert.True(t, VerifyAggregateSignature(curve, AggregateSignatures(sigs), keys, msgs),
+			"Aggregate of batch signatures failed verification")
+		empty := SignBatch(curve, sk, [][]byte{})
+		assert.NotNil(t, empty)
+		assert.Equal(t, 0, len(empty))
+	}
+}
+
+func TestAggregateValid(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 8, 32
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		pubkeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			pubkeys[i] = vk
+		}
+		// Invalidate signatures 1, 4 and 5 in different ways
+		sigs[1], _ = sigs[1].Add(curve.GetG1())
+		sigs[4] = sigs[5]
+		sigs[5] = nil
+		aggSig, valid := AggregateValid(curve, pubkeys, msgs, sigs)
+		assert.Equal(t, []int{0, 2, 3, 6, 7}, valid)
+		validKeys := make([]Point, len(valid))
+		validMsgs := make([][]byte, len(valid))
+		for i, j := range valid {
+			validKeys[i], validMsgs[i] = pubkeys[j], msgs[j]
+		}
+		assert.True(t, VerifyAggregateSignature(curve, aggSig, validKeys, validMsgs),
+			"Aggregate of valid signatures failed verification")
+
+		aggSig, valid = AggregateValid(curve, pubkeys[4:6], msgs[4:6], sigs[4:6])
+		assert.Nil(t, aggSig, "Aggregate signature returned with no valid signatures")
+		assert.Equal(t, 0, len(valid))
+		_, valid = AggregateValid(curve, pubkeys, msgs[1:], sigs)
+		assert.Equal(t, 0, len(valid), "Valid signatures returned with mismatched lengths")
+	}
+}
+
+func TestVerifyAggregateLocating(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 8, 32
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		pubkeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			pubkeys[i] = vk
+		}
+		ok, invalid := VerifyAggregateLocating(curve, AggregateSignatures(sigs), pubkeys, msgs, sigs)
+		assert.True(t, ok, "Valid aggregate failed verification")
+		assert.Equal(t, 0, len(invalid))
+
+		// Plant two bad signers
+		sigs[2], _ = sigs[2].Add(curve.GetG1())
+		sigs[6] = Sign(curve, big.NewInt(3), msgs[6])
+		ok, invalid = VerifyAggregateLocating(curve, AggregateSignatures(sigs), pubkeys, msgs, sigs)
+		assert.False(t, ok, "Invalid aggregate verified")
+		assert.Equal(t, []int{2, 6}, invalid, "Bad signers weren't located")
+
+		_, invalid = VerifyAggregateLocating(curve, AggregateSignatures(sigs), pubkeys, msgs[1:], sigs)
+		assert.Nil(t, invalid, "Indices returned with mismatched lengths")
+	}
+}
+
+func TestAggregationIndexed(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 6, 32
+		table := make([][]byte, 2*N)
+		for i := 0; i < len(table); i++ {
+			table[i] = make([]byte, Size)
+			rand.Read(table[i])
+		}
+		indices := make([]int, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		pubkeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			indices[i] = 2*N - 1 - 2*i
+			msgs[i] = table[indices[i]]
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			pubkeys[i] = vk
+		}
+		aggSig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateSignature(curve, aggSig, pubkeys, msgs),
+			"Aggregate signature verification failed")
+		assert.True(t, VerifyAggregateSignatureIndexed(curve, aggSig, pubkeys, table, indices),
+			"Indexed aggregate signature verification failed")
+		assert.False(t, VerifyAggregateSignatureIndexed(curve, aggSig, pubkeys, table, indices[:N-1]),
+			"Indexed aggregate signature succeeding without enough indices")
+
+		badIndices := append([]int{}, indices...)
+		badIndices[0] = len(table)
+		assert.False(t, VerifyAggregateSignatureIndexed(curve, aggSig, pubkeys, table, badIndices),
+			"Indexed aggregate signature succeeding with an out of range index")
+		badIndices[0] = -1
+		assert.False(t, VerifyAggregateSignatureIndexed(curve, aggSig, pubkeys, table, badIndices),
+			"Indexed aggregate signature succeeding with a negative index")
+		badIndices[0] = indices[1]
+		assert.False(t, VerifyAggregateSignatureIndexed(curve, aggSig, pubkeys, table, badIndices),
+			"Indexed aggregate signature succeeding with a wrong index")
+	}
+}
+
+func TestTwoMessageAggregate(t *testing.T) {
+	for _, curve := range curves {
+		NA, NB, Size := 4, 3, 32
+		msgA := make([]byte, Size)
+		msgB := make([]byte, Size)
+		rand.Read(msgA)
+		rand.Read(msgB)
+		keysA := make([]Point, NA)
+		keysB := make([]Point, NB)
+		sigsA := make([]Point, NA)
+		sigsB := make([]Point, NB)
+		for i := 0; i < NA; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keysA[i], sigsA[i] = vk, Sign(curve, sk, msgA)
+		}
+		for i := 0; i < NB; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keysB[i], sigsB[i] = vk, Sign(curve, sk, msgB)
+		}
+		aggSig := AggregateSignatures(append(append([]Point{}, sigsA...), sigsB...))
+		assert.True(t, VerifyTwoMessageAggregate(curve, aggSig, keysA, msgA, keysB, msgB),
+			"Two message aggregate verification failed")
+		assert.False(t, VerifyTwoMessageAggregate(curve, aggSig, keysB, msgA, keysA, msgB),
+			"Two message aggregate succeeding with the groups swapped")
+		assert.False(t, VerifyTwoMessageAggregate(curve, aggSig, keysA, msgA, keysB, msgA),
+			"Two message aggregate succeeding with identical messages")
+
+		aggSigA := AggregateSignatures(sigsA)
+		assert.True(t, VerifyTwoMessageAggregate(curve, aggSigA, keysA, msgA, []Point{}, msgB),
+			"Two message aggregate failed with the second group empty")
+		aggSigB := AggregateSignatures(sigsB)
+		assert.True(t, VerifyTwoMessageAggregate(curve, aggSigB, nil, msgA, keysB, msgB),
+			"Two message aggregate failed with the first group empty")
+		assert.False(t, VerifyTwoMessageAggregate(curve, aggSigB, keysB, msgA, nil, msgB),
+			"Two message aggregate succeeding with the wrong message")
+		assert.False(t, VerifyTwoMessageAggregate(curve, aggSig, nil, msgA, nil, msgB),
+			"Two message aggregate succeeding with no keys")
+	}
+}
+
+func TestUpdateAggregateKey(t *testing.T) {
+	for _, curve := range curves {
+		N := 10
+		roster := make([]Point, N)
+		for i := 0; i < N; i++ {
+			_, roster[i], _ = KeyGen(curve)
+		}
+		apk := AggregateKeys(roster)
+		_, new1, _ := KeyGen(curve)
+		_, new2, _ := KeyGen(curve)
+		removed := []Point{roster[2], roster[7]}
+		newRoster := append([]Point{}, roster...)
+		newRoster[2], newRoster[7] = new1, new2
+		updated := UpdateAggregateKey(apk, removed, []Point{new1, new2})
+		assert.True(t, updated.Equals(AggregateKeys(newRoster)),
+			"Updated aggregate key doesn't match the new roster's aggregate key")
+		assert.True(t, UpdateAggregateKey(apk, nil, nil).Equals(apk),
+			"Updating with no changes altered the aggregate key")
+		assert.True(t, UpdateAggregateKey(updated, []Point{new1, new2}, removed).Equals(apk),
+			"Reverting an update didn't restore the aggregate key")
+	}
+}
+
+func TestSubtractSignature(t *testing.T) {
+	for _, curve := range curves {
+		msg := []byte("message")
+		sks := make([]*big.Int, 3)
+		keys := make([]Point, 3)
+		sigs := make([]Point, 3)
+		for i := 0; i < 3; i++ {
+			sks[i], keys[i], _ = KeyGen(curve)
+			sigs[i] = KoskSign(curve, sks[i], msg)
+		}
+		aggsig := AggregateSignatures(sigs)
+		apk := AggregateKeys(keys)
+		remaining := SubtractSignature(aggsig, sigs[1])
+		assert.True(t, KoskVerifyMultiSignature(curve, remaining, []Point{keys[0], keys[2]}, msg),
+			"Aggregate with a signature subtracted failed verification against the remaining keys")
+		assert.False(t, KoskVerifyMultiSignature(curve, remaining, keys, msg),
+			"Aggregate with a signature subtracted passed verification against every key")
+		reducedKey := SubtractKey(apk, keys[1])
+		assert.True(t, reducedKey.Equals(AggregateKeys([]Point{keys[0], keys[2]})))
+		assert.True(t, KoskVerifySingleSignature(curve, remaining, reducedKey, msg),
+			"Aggregate with a signature subtracted failed verification against the reduced key")
+	}
+}
+
+func TestComplementSignature(t *testing.T) {
+	for _, curve := range curves {
+		msg := []byte("message")
+		N := 5
+		keys := make([]Point, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			sigs[i] = KoskSign(curve, sk, msg)
+		}
+		full := AggregateSignatures(sigs)
+		partial := AggregateSignatures([]Point{sigs[0], sigs[2], sigs[3]})
+		nonSigners := []Point{keys[1], keys[4]}
+		complement := ComplementSignature(full, partial)
+		assert.True(t, KoskVerifyMultiSignature(curve, complement, nonSigners, msg),
+			"Complement signature failed verification against the non signers")
+		assert.False(t, KoskVerifyMultiSignature(curve, complement, keys[:2], msg),
+			"Complement signature passed verification against signers")
+
+		complementKey := ComplementKeys(AggregateKeys(keys), AggregateKeys([]Point{keys[0], keys[2], keys[3]}))
+		assert.True(t, complementKey.Equals(AggregateKeys(nonSigners)), "Complement key isn't the non signers' key")
+		assert.True(t, KoskVerifySingleSignature(curve, complement, complementKey, msg),
+			"Complement signature failed verification against the complement key")
+	}
+}
+
 func BenchmarkKeygen(b *testing.B) {
 	b.ResetTimer()
 	curve := Altbn128
@@ -116,6 +926,61 @@ func BenchmarkSigning(b *testing.B) {
 	}
 }
 
+func benchmarkBatchMessages(n int) [][]byte {
+	ms := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		ms[i] = make([]byte, 64)
+		rand.Read(ms[i])
+	}
+	return ms
+}
+
+func BenchmarkSignBatch64(b *testing.B) {
+	sk, _, _ := KeyGen(benchmarkCurve)
+	ms := benchmarkBatchMessages(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SignBatch(benchmarkCurve, sk, ms)
+	}
+}
+
+func BenchmarkSignSerial64(b *testing.B) {
+	sk, _, _ := KeyGen(benchmarkCurve)
+	ms := benchmarkBatchMessages(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < len(ms); j++ {
+			Sign(benchmarkCurve, sk, ms[j])
+		}
+	}
+}
+
+func BenchmarkSignMany64(b *testing.B) {
+	sks := make([]*big.Int, 64)
+	for i := 0; i < len(sks); i++ {
+		sks[i], _, _ = KeyGen(benchmarkCurve)
+	}
+	ms := benchmarkBatchMessages(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SignMany(benchmarkCurve, sks, ms)
+	}
+}
+
+func BenchmarkSignManySerial64(b *testing.B) {
+	sks := make([]*big.Int, 64)
+	for i := 0; i < len(sks); i++ {
+		sks[i], _, _ = KeyGen(benchmarkCurve)
+	}
+	ms := benchmarkBatchMessages(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < len(ms); j++ {
+			Sign(benchmarkCurve, sks[j], ms[j])
+		}
+	}
+}
+
 func BenchmarkVerification(b *testing.B) {
 	curve := Altbn128
 	message := make([]byte, 64)
@@ -130,6 +995,65 @@ func BenchmarkVerification(b *testing.B) {
 	}
 }
 
+func benchAggregateVerify(b *testing.B, k int, verify func(CurveSystem, Point, []Point, [][]byte) bool) {
+	keys := make([]Point, k)
+	sigs := make([]Point, k)
+	ms := benchmarkBatchMessages(k)
+	for i := 0; i < k; i++ {
+		sk, vk, _ := KeyGen(benchmarkCurve)
+		keys[i] = vk
+		sigs[i] = Sign(benchmarkCurve, sk, ms[i])
+	}
+	aggsig := AggregateSignatures(sigs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !verify(benchmarkCurve, aggsig, keys, ms) {
+			b.Error("Aggregate signature verification failed")
+		}
+	}
+}
+
+func BenchmarkAggregateVerify64(b *testing.B) {
+	benchAggregateVerify(b, 64, VerifyAggregateSignature)
+}
+
+func BenchmarkAggregateVerifyNoDupCheck64(b *testing.B) {
+	benchAggregateVerify(b, 64, VerifyAggregateSignatureNoDupCheck)
+}
+
+// BenchmarkAggregateVerify100000 measures verification of a very large distinct
+// message aggregate. One key signs every message, as generating 100000 keys
+// would dominate the setup.
+func BenchmarkAggregateVerify100000(b *testing.B) {
+	k := 100000
+	sk, vk, _ := KeyGen(benchmarkCurve)
+	keys := make([]Point, k)
+	ms := benchmarkBatchMessages(k)
+	for i := 0; i < k; i++ {
+		keys[i] = vk
+	}
+	aggsig := AggregateSignatures(HashToG1Batch(benchmarkCurve, ms)).Mul(sk)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyAggregateSignature(benchmarkCurve, aggsig, keys, ms) {
+			b.Error("Aggregate signature verification failed")
+		}
+	}
+}
+
+// BenchmarkDuplicateMessageScan4096 measures the scan which
+// VerifyAggregateSignatureNoDupCheck skips.
+func BenchmarkDuplicateMessageScan4096(b *testing.B) {
+	ms := benchmarkBatchMessages(4096)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if containsDuplicateMessage(ms) {
+			b.Error("Unexpected duplicate message")
+		}
+	}
+}
+
 var vks []Point
 var sgs []Point
 var msg []byte
@@ -159,6 +1083,32 @@ func benchmulti(b *testing.B, k int) {
 	}
 }
 
+func benchTwoMessage(b *testing.B, k int) {
+	msgB := make([]byte, 64)
+	rand.Read(msgB)
+	// The signatures on msg are kosk signatures, so the first group's message
+	// has the kosk prefix prepended.
+	msgA := append([]byte{1}, msg...)
+	sk, vk, _ := KeyGen(benchmarkCurve)
+	aggsig, _ := AggregateSignatures(sgs[:k]).Add(Sign(benchmarkCurve, sk, msgB))
+	keysA := vks[:k]
+	keysB := []Point{vk}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !VerifyTwoMessageAggregate(benchmarkCurve, aggsig, keysA, msgA, keysB, msgB) {
+			b.Error("Two message aggregate verification failed")
+		}
+	}
+}
+
+func BenchmarkTwoMessageVerification64(b *testing.B) {
+	benchTwoMessage(b, 64)
+}
+
+func BenchmarkTwoMessageVerification2048(b *testing.B) {
+	benchTwoMessage(b, 2048)
+}
+
 func BenchmarkMultiVerification64(b *testing.B) {
 	benchmulti(b, 64)
 }
@@ -0,0 +1,108 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements multi signatures where the signers are a subset of a
+// known roster of public keys, described by a bitfield. Bit i of the bitfield
+// is (bitfield[i/8] >> (i%8)) & 1, and is set if roster[i] signed. The bitfield
+// must be exactly as long as is needed to describe the roster, and any bits past
+// the end of the roster must be unset.
+//
+// As with all multi signatures, this is vulnerable to the rogue public key attack
+// unless the keys in the roster have been protected by one of the defense
+// mechanisms. See doc.go for more details.
+
+import (
+	"math/bits"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// VerifyAggregateWithRoster verifies that aggsig is a multi signature on msg by
+// exactly the keys in the roster which are marked in the bitfield. It also
+// returns the number of signers marked in the bitfield, which is 0 if the
+// bitfield is malformed.
+func VerifyAggregateWithRoster(curve CurveSystem, roster []Point, bitfield []byte,
+	aggsig Point, msg []byte) (bool, int) {
+	keys, ok := participantKeys(roster, bitfield)
+	if !ok || len(keys) == 0 {
+		return false, 0
+	}
+	return verifyMultiSignature(curve, aggsig, keys, msg), len(keys)
+}
+
+// VerifyCompactProof verifies a compact proof that the signers marked in the
+// bitfield signed msg, which is the committee's fixed aggregate public key, the
+// keys of the members not marked, in committee order, and the multi signature.
+// The non signers' keys are subtracted from the committee's aggregate key, and
+// the signature checked against the result with two pairings. The committee's
+// size is taken to be the number of signers plus non signers, which the bitfield
+// must describe as in VerifyAggregateWithRoster, with at least one signer.
+//
+// Neither the bitfield nor the committee's size is bound by the aggregate key,
+// so the caller must check that the signers and non signers number the whole
+// committee, and that the non signers' keys are the committee's keys at its
+// unset bits, e.g. with a KeyMerkleProof for each. Otherwise a rogue "non
+// signer" could make the remaining aggregate key any key at all.
+func VerifyCompactProof(curve CurveSystem, committeeAPK Point, nonSignerKeys []Point, bitfield []byte,
+	aggsig Point, msg []byte) bool {
+	requireCurve(curve, "VerifyCompactProof")
+	signers := ParticipationCount(bitfield)
+	size := signers + len(nonSignerKeys)
+	if signers == 0 || len(bitfield) != (size+7)/8 {
+		return false
+	}
+	for i := size; i < 8*len(bitfield); i++ {
+		if (bitfield[i/8]>>uint(i%8))&1 != 0 {
+			return false
+		}
+	}
+	if CheckCurve(curve, committeeAPK) != nil || CheckCurve(curve, nonSignerKeys...) != nil {
+		return false
+	}
+	apk := committeeAPK
+	if len(nonSignerKeys) > 0 {
+		apk = SubtractKey(committeeAPK, AggregateKeys(nonSignerKeys))
+	}
+	return verifyMultiSignature(curve, aggsig, []Point{apk}, msg)
+}
+
+// ParticipationCount returns the number of signers marked in the bitfield.
+func ParticipationCount(bitfield []byte) int {
+	count := 0
+	for _, b := range bitfield {
+		count += bits.OnesCount8(b)
+	}
+	return count
+}
+
+// ParticipantKeys returns the keys in the roster which are marked in the
+// bitfield, in roster order. This is nil if the bitfield is malformed for the
+// roster.
+func ParticipantKeys(roster []Point, bitfield []byte) []Point {
+	keys, ok := participantKeys(roster, bitfield)
+	if !ok {
+		return nil
+	}
+	return keys
+}
+
+// participantKeys returns the keys in roster which are marked in the bitfield.
+// This fails if the bitfield is malformed.
+func participantKeys(roster []Point, bitfield []byte) ([]Point, bool) {
+	if len(bitfield) != (len(roster)+7)/8 {
+		return nil, false
+	}
+	keys := make([]Point, 0, len(roster))
+	for i := 0; i < 8*len(bitfield); i++ {
+		if (bitfield[i/8]>>uint(i%8))&1 == 0 {
+			continue
+		}
+		if i >= len(roster) {
+			return nil, false
+		}
+		keys = append(keys, roster[i])
+	}
+	return keys, true
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyAggregateWithRoster(t *testing.T) {
+	for _, curve := range curves {
+		N := 11
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		roster := make([]Point, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			roster[i] = vk
+			sigs[i] = Sign(curve, sk, msg)
+		}
+		// Signers 0, 3, 4 and 9
+		bitfield := []byte{0x19, 0x02}
+		aggsig := AggregateSignatures([]Point{sigs[0], sigs[3], sigs[4], sigs[9]})
+		ok, count := VerifyAggregateWithRoster(curve, roster, bitfield, aggsig, msg)
+		assert.True(t, ok, "Roster aggregate verification failed")
+		assert.Equal(t, 4, count)
+
+		ok, count = VerifyAggregateWithRoster(curve, roster, []byte{0x1b, 0x02}, aggsig, msg)
+		assert.False(t, ok, "Roster aggregate succeeding with an extra signer claimed")
+		assert.Equal(t, 5, count)
+		ok, _ = VerifyAggregateWithRoster(curve, roster, []byte{0x18, 0x02}, aggsig, msg)
+		assert.False(t, ok, "Roster aggregate succeeding with a missing signer")
+
+		ok, count = VerifyAggregateWithRoster(curve, roster, []byte{0x19, 0x0a}, aggsig, msg)
+		assert.False(t, ok, "Roster aggregate succeeding with a bit set past the roster")
+		assert.Equal(t, 0, count)
+		ok, _ = VerifyAggregateWithRoster(curve, roster, []byte{0x19}, aggsig, msg)
+		assert.False(t, ok, "Roster aggregate succeeding with a short bitfield")
+		ok, _ = VerifyAggregateWithRoster(curve, roster, []byte{0, 0}, curve.GetG1Infinity(), msg)
+		assert.False(t, ok, "Roster aggregate succeeding with no signers")
+	}
+}
+
+func TestVerifyCompactProof(t *testing.T) {
+	for _, curve := range curves {
+		N := 5
+		msg := []byte("light client update")
+		roster := make([]Point, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			roster[i] = vk
+			sigs[i] = Sign(curve, sk, msg)
+		}
+		committeeAPK := AggregateKeys(roster)
+		// Signers 0, 2 and 3, so 1 and 4 didn't sign
+		bitfield := []byte{0x0d}
+		nonSigners := []Point{roster[1], roster[4]}
+		aggsig := AggregateSignatures([]Point{sigs[0], sigs[2], sigs[3]})
+		assert.True(t, VerifyCompactProof(curve, committeeAPK, nonSigners, bitfield, aggsig, msg),
+			"Compact proof failed verification")
+
+		assert.False(t, VerifyCompactProof(curve, committeeAPK, nonSigners, bitfield, aggsig, []byte("other")),
+			"Compact proof verified on the wrong message")
+		assert.False(t, VerifyCompactProof(curve, committeeAPK, []Point{roster[1], roster[3]}, bitfield, aggsig, msg),
+			"Compact proof verified with the wrong non signers")
+		assert.False(t, VerifyCompactProof(curve, committeeAPK, nonSigners[:1], bitfield, aggsig, msg),
+			"Compact proof verified with a missing non signer")
+		assert.False(t, VerifyCompactProof(curve, committeeAPK, nonSigners, []byte{0x8d}, aggsig, msg),
+			"Compact proof verified with a bit set past the committee")
+		assert.False(t, VerifyCompactProof(curve, committeeAPK, roster, []byte{0x00}, curve.GetG1Infinity(), msg),
+			"Compact proof verified without signers")
+
+		all := AggregateSignatures(sigs)
+		assert.True(t, VerifyCompactProof(curve, committeeAPK, []Point{}, []byte{0x1f}, all, msg),
+			"Compact proof with every signer failed verification")
+	}
+}
+
+func TestParticipantKeys(t *testing.T) {
+	for _, curve := range curves {
+		N := 11
+		roster := make([]Point, N)
+		for i := 0; i < N; i++ {
+			_, roster[i], _ = KeyGen(curve)
+		}
+		// Participants 0, 3, 4 and 9
+		bitfield := []byte{0x19, 0x02}
+		assert.Equal(t, 4, ParticipationCount(bitfield))
+		keys := ParticipantKeys(roster, bitfield)
+		assert.Equal(t, 4, len(keys))
+		for i, j := range []int{0, 3, 4, 9} {
+			assert.True(t, keys[i].Equals(roster[j]), "Participant keys don't match the bitfield")
+		}
+		assert.Equal(t, 0, len(ParticipantKeys(roster, []byte{0, 0})))
+		assert.Equal(t, 0, ParticipationCount([]byte{0, 0}))
+		assert.Equal(t, 11, ParticipationCount([]byte{0xff, 0x07}))
+		assert.Nil(t, ParticipantKeys(roster, []byte{0x19}), "Short bitfield accepted")
+		assert.Nil(t, ParticipantKeys(roster, []byte{0x19, 0x0a}), "Bit past the roster accepted")
+	}
+}
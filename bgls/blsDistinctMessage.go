@@ -55,3 +55,23 @@ func DistinctMsgVerifyAggregateSignature(curve CurveSystem, aggsig Point, keys [
 	// So setting this to true skips that check.
 	return verifyAggSig(curve, aggsig, keys, prependedMsgs, true)
 }
+
+// VerifyMixedAggregate checks an aggsig generated from signers of which some used
+// the 'Distinct Message' method, and others signed their messages plainly.
+// augmented[i] is true if keys[i] signed msgs[i] with the 'Distinct Message'
+// method. The signed messages must all be distinct, so plain signers must have
+// signed distinct messages, as with VerifyAggregateSignature.
+func VerifyMixedAggregate(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, augmented []bool) bool {
+	if len(keys) != len(msgs) || len(keys) != len(augmented) {
+		return false
+	}
+	signedMsgs := make([][]byte, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		if augmented[i] {
+			signedMsgs[i] = append(keys[i].MarshalUncompressed(), msgs[i]...)
+		} else {
+			signedMsgs[i] = msgs[i]
+		}
+	}
+	return verifyAggSig(curve, aggsig, keys, signedMsgs, false)
+}
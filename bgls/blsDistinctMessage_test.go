@@ -55,3 +55,37 @@ func TestDistinctMsgAggregation(t *testing.T) {
 		// TODO Add tests to make sure there is no mutation
 	}
 }
+
+func TestVerifyMixedAggregate(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 6, 32
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		pubkeys := make([]Point, N)
+		augmented := make([]bool, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+
+			sk, vk, _ := KeyGen(curve)
+			augmented[i] = i%2 == 0
+			if augmented[i] {
+				sigs[i] = DistinctMsgSign(curve, sk, msgs[i])
+			} else {
+				sigs[i] = Sign(curve, sk, msgs[i])
+			}
+			pubkeys[i] = vk
+		}
+		aggSig := AggregatePoints(sigs)
+		assert.True(t, VerifyMixedAggregate(curve, aggSig, pubkeys, msgs, augmented),
+			"Mixed aggregate verification failed")
+		assert.False(t, VerifyMixedAggregate(curve, aggSig, pubkeys, msgs, augmented[:N-1]),
+			"Mixed aggregate verification succeeding with mismatched lengths")
+		assert.False(t, VerifyMixedAggregate(curve, aggSig, pubkeys[:N-1], msgs[:N-1], augmented[:N-1]),
+			"Mixed aggregate verification succeeding without enough pubkeys")
+		flipped := append([]bool{}, augmented...)
+		flipped[1] = true
+		assert.False(t, VerifyMixedAggregate(curve, aggSig, pubkeys, msgs, flipped),
+			"Mixed aggregate verification succeeding with the wrong augmentation")
+	}
+}
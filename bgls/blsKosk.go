@@ -30,10 +30,11 @@ package bgls
 //
 // If you are using Kosk to secure against the rogue public key attack, you are
 // intended to use: AggregateSignatures, KeyGen, KoskSign,
-// KoskVerifySingleSignature, KoskVerifyMultiSignature
+// KoskVerifySingleSignature, KoskVerifyMultiSignature, FastAggregateVerify
 // KoskVerifyMultiSignatureWithMultiplicity, KoskVerifyAggregateSignature
 
 import (
+	"crypto/rand"
 	"math/big"
 
 	. "github.com/orbs-network/bgls/curves" // nolint: golint
@@ -68,9 +69,63 @@ func CheckAuthenticationCustHash(curve CurveSystem, pubkey Point, authentication
 	return VerifySingleSignatureCustHash(curve, authentication, pubkey, msg, hash)
 }
 
+// BatchVerifyProofsOfPossession checks the authentication of each public key,
+// where pops[i] is the authentication of pubKeys[i]. It returns whether each
+// key's authentication is valid, or an empty slice if the number of keys and
+// authentications differ. All of the authentications are first checked together
+// by one pairing product, with each scaled by a random 128 bit weight as in
+// VerifyTwoSignatures. Only if that fails is each checked on its own, across a
+// pool of workers, to find the invalid ones.
+func BatchVerifyProofsOfPossession(curve CurveSystem, pubKeys []Point, pops []Point) []bool {
+	requireCurve(curve, "BatchVerifyProofsOfPossession")
+	if len(pubKeys) != len(pops) {
+		return []bool{}
+	}
+	msgs := make([][]byte, len(pubKeys))
+	for i := 0; i < len(pubKeys); i++ {
+		if pubKeys[i] != nil {
+			msgs[i] = pubKeys[i].Marshal()
+		}
+	}
+	if !batchCheckAuthentication(curve, pubKeys, pops, msgs) {
+		return verifySignatures(curve, pubKeys, msgs, pops)
+	}
+	valid := make([]bool, len(pubKeys))
+	for i := 0; i < len(valid); i++ {
+		valid[i] = true
+	}
+	return valid
+}
+
+// batchCheckAuthentication checks that every pops[i] is a signature on msgs[i],
+// the marshalled pubKeys[i], with a single pairing product over the randomly
+// weighted hashes and the weighted sum of the signatures.
+func batchCheckAuthentication(curve CurveSystem, pubKeys []Point, pops []Point, msgs [][]byte) bool {
+	if len(pubKeys) == 0 || CheckCurve(curve, pubKeys...) != nil || CheckCurve(curve, pops...) != nil {
+		return false
+	}
+	weights := make([]*big.Int, len(pubKeys))
+	for i := 0; i < len(weights); i++ {
+		r, err := rand.Int(rand.Reader, batchWeightBound)
+		if err != nil {
+			return false
+		}
+		weights[i] = r.Add(r, big.NewInt(1))
+	}
+	aggPop := AggregatePoints(ScalePoints(pops, weights))
+	if aggPop == nil {
+		return false
+	}
+	pts1 := append(ScalePoints(HashToG1Batch(curve, msgs), weights), aggPop.Neg())
+	pts2 := append(append([]Point{}, pubKeys...), curve.GetG2())
+	paired, ok := curve.PairingProduct(pts1, pts2)
+	return ok && paired.Equals(curve.GetGTIdentity())
+}
+
 // KoskSign creates a kosk signature on a message with a private key.
 // A kosk signature prepends a 0x01 byte to the message before signing.
 func KoskSign(curve CurveSystem, sk *big.Int, msg []byte) Point {
+	requireCurve(curve, "KoskSign")
 	return KoskSignCustHash(curve, sk, msg, curve.HashToG1)
 }
 
@@ -115,10 +170,35 @@ func (m MultiSig) Verify(curve CurveSystem) bool {
 // that a single message has been signed by a set of keys,
 // vulnerable against chosen key attack, if keys have not been authenticated
 func KoskVerifyMultiSignature(curve CurveSystem, aggsig Point, keys []Point, msg []byte) bool {
+	requireCurve(curve, "KoskVerifyMultiSignature")
 	msg2 := append([]byte{1}, msg...)
 	return verifyMultiSignature(curve, aggsig, keys, msg2)
 }
 
+// FastAggregateVerify checks that aggsig is an aggregate of kosk signatures on
+// msg by every one of pubKeys, by aggregating the keys and checking a single
+// product of two pairings, regardless of the number of keys. This is only safe
+// against the rogue public key attack if every key's authentication (its proof
+// of possession) was checked with CheckAuthentication when it was registered.
+func FastAggregateVerify(curve CurveSystem, pubKeys []Point, msg []byte, aggsig Point) bool {
+	if len(pubKeys) == 0 || aggsig == nil {
+		return false
+	}
+	return KoskVerifyMultiSignature(curve, aggsig, pubKeys, msg)
+}
+
+// VerifyHierarchicalAggregate checks a two level aggregation tree, where each
+// sub committee contributes its aggregate public key and its aggregate kosk
+// signature on msg. The sub committee keys and signatures are each aggregated,
+// and checked with a single product of two pairings, as in FastAggregateVerify.
+// Every key in every sub committee must have had its authentication checked.
+func VerifyHierarchicalAggregate(curve CurveSystem, subAPKs []Point, subAggSigs []Point, msg []byte) bool {
+	if len(subAPKs) == 0 || len(subAPKs) != len(subAggSigs) {
+		return false
+	}
+	return FastAggregateVerify(curve, subAPKs, msg, AggregateSignatures(subAggSigs))
+}
+
 // KoskVerifyMultiSignatureWithMultiplicity verifies a BLS multi signature where
 // multiple copies of each signature may have been included in the aggregation
 func KoskVerifyMultiSignatureWithMultiplicity(curve CurveSystem, aggsig Point, keys []Point,
@@ -32,6 +32,45 @@ func TestKoskSingleSigner(t *testing.T) {
 	}
 }
 
+func TestBatchVerifyProofsOfPossession(t *testing.T) {
+	for _, curve := range curves {
+		N, invalid := 40, 17
+		keys := make([]Point, N)
+		pops := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			pops[i] = Authenticate(curve, sk)
+		}
+		// A kosk signature doesn't authenticate a key
+		sk, _, _ := KeyGen(curve)
+		keys[invalid] = LoadPublicKey(curve, sk)
+		pops[invalid] = KoskSign(curve, sk, keys[invalid].Marshal())
+		valid := BatchVerifyProofsOfPossession(curve, keys, pops)
+		assert.Equal(t, N, len(valid))
+		for i := 0; i < N; i++ {
+			assert.Equal(t, i != invalid, valid[i], "Wrong validity for authentication %d", i)
+		}
+		assert.Equal(t, 0, len(BatchVerifyProofsOfPossession(curve, keys, pops[1:])))
+
+		// Valid authentications are checked by a single pairing product
+		pops[invalid] = Authenticate(curve, sk)
+		counter := &pairingCounter{CurveSystem: curve}
+		valid = BatchVerifyProofsOfPossession(counter, keys, pops)
+		for i := 0; i < N; i++ {
+			assert.True(t, valid[i], "Authentication %d failed verification", i)
+		}
+		assert.Equal(t, N+1, counter.pairings, "Valid authentications weren't batched")
+
+		// A missing authentication only fails its own key
+		pops[3] = nil
+		valid = BatchVerifyProofsOfPossession(curve, keys, pops)
+		for i := 0; i < N; i++ {
+			assert.Equal(t, i != 3, valid[i], "Wrong validity for authentication %d", i)
+		}
+	}
+}
+
 func TestKoskMultiSig(t *testing.T) {
 	for _, curve := range curves {
 		Tests, Size, Signers := 5, 32, 10
@@ -63,6 +102,78 @@ func TestKoskMultiSig(t *testing.T) {
 	}
 }
 
+// pairingCounter counts the number of pairings computed by the curve.
+type pairingCounter struct {
+	CurveSystem
+	pairings int
+}
+
+func (c *pairingCounter) PairingProduct(g1Points []Point, g2Points []Point) (PointT, bool) {
+	c.pairings += len(g1Points)
+	return c.CurveSystem.PairingProduct(g1Points, g2Points)
+}
+
+func TestFastAggregateVerify(t *testing.T) {
+	for _, curve := range curves {
+		N := 50
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		keys := make([]Point, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			assert.True(t, CheckAuthentication(curve, vk, Authenticate(curve, sk)))
+			keys[i] = vk
+			sigs[i] = KoskSign(curve, sk, msg)
+		}
+		aggsig := AggregateSignatures(sigs)
+		counter := &pairingCounter{CurveSystem: curve}
+		assert.True(t, FastAggregateVerify(counter, keys, msg, aggsig), "FastAggregateVerify failed")
+		assert.Equal(t, 2, counter.pairings, "FastAggregateVerify didn't use two pairings")
+
+		msg2 := make([]byte, 32)
+		rand.Read(msg2)
+		assert.False(t, FastAggregateVerify(curve, keys, msg2, aggsig), "FastAggregateVerify succeeded on the wrong message")
+		assert.False(t, FastAggregateVerify(curve, keys[1:], msg, aggsig), "FastAggregateVerify succeeded with a missing key")
+		assert.False(t, FastAggregateVerify(curve, []Point{}, msg, aggsig), "FastAggregateVerify succeeded without keys")
+	}
+}
+
+func TestVerifyHierarchicalAggregate(t *testing.T) {
+	for _, curve := range curves {
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		subAPKs := make([]Point, 2)
+		subAggSigs := make([]Point, 2)
+		for c := 0; c < 2; c++ {
+			keys := make([]Point, 3+c)
+			sigs := make([]Point, 3+c)
+			for i := 0; i < len(keys); i++ {
+				sk, vk, _ := KeyGen(curve)
+				keys[i] = vk
+				sigs[i] = KoskSign(curve, sk, msg)
+			}
+			subAPKs[c] = AggregateKeys(keys)
+			subAggSigs[c] = AggregateSignatures(sigs)
+		}
+		counter := &pairingCounter{CurveSystem: curve}
+		assert.True(t, VerifyHierarchicalAggregate(counter, subAPKs, subAggSigs, msg),
+			"Hierarchical aggregate verification failed")
+		assert.Equal(t, 2, counter.pairings)
+
+		msg2 := make([]byte, 32)
+		rand.Read(msg2)
+		assert.False(t, VerifyHierarchicalAggregate(curve, subAPKs, subAggSigs, msg2),
+			"Hierarchical aggregate verification succeeded on the wrong message")
+		assert.False(t, VerifyHierarchicalAggregate(curve, subAPKs, subAggSigs[:1], msg),
+			"Hierarchical aggregate verification succeeded with a missing sub signature")
+		assert.True(t, VerifyHierarchicalAggregate(curve, subAPKs[:1], subAggSigs[:1], msg),
+			"Hierarchical aggregate verification failed with one sub committee")
+		assert.False(t, VerifyHierarchicalAggregate(curve, subAPKs, []Point{subAggSigs[0], subAggSigs[0]}, msg),
+			"Hierarchical aggregate verification succeeded with a repeated sub signature")
+	}
+}
+
 func TestKoskMultiSigWithMultiplicity(t *testing.T) {
 	for _, curve := range curves {
 		Tests, Size, Signers := 5, 32, 10
@@ -0,0 +1,87 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements length prefixed signatures, for messages built from
+// several parts. The message that is actually signed is a fixed domain tag,
+// followed by each part with its length as 8 big endian bytes before it. So the
+// signature binds where each part ends, and parts which concatenate to the same
+// bytes, e.g. "ab", "c" and "a", "bc", produce different signatures.
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// lengthPrefixedTag is prepended to length prefixed messages before signing.
+var lengthPrefixedTag = []byte("BGLS_LENGTH_PREFIXED")
+
+// fieldsTag is prepended, followed by the domain, to messages signed with
+// SignFields.
+var fieldsTag = []byte("BGLS_FIELDS")
+
+// lengthPrefixedMessage returns the message which is signed for the parts.
+func lengthPrefixedMessage(prefix []byte, parts [][]byte) []byte {
+	size := len(prefix)
+	for _, part := range parts {
+		size += 8 + len(part)
+	}
+	m := make([]byte, len(prefix), size)
+	copy(m, prefix)
+	var length [8]byte
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		m = append(m, length[:]...)
+		m = append(m, part...)
+	}
+	return m
+}
+
+// SignLengthPrefixed creates a signature on the message made of parts with a
+// private key, binding the length of each part into the signature.
+func SignLengthPrefixed(curve CurveSystem, sk *big.Int, parts ...[]byte) Point {
+	return SignLengthPrefixedCustHash(sk, curve.HashToG1, parts...)
+}
+
+// SignLengthPrefixedCustHash creates a signature on the message made of parts
+// with a private key, binding the length of each part into the signature, using
+// a supplied function to hash to g1.
+func SignLengthPrefixedCustHash(sk *big.Int, hash func([]byte) Point, parts ...[]byte) Point {
+	return SignCustHash(sk, lengthPrefixedMessage(lengthPrefixedTag, parts), hash)
+}
+
+// VerifyLengthPrefixed checks that sig is a length prefixed signature by pubKey
+// on the message made of parts.
+func VerifyLengthPrefixed(curve CurveSystem, sig Point, pubKey Point, parts ...[]byte) bool {
+	return VerifyLengthPrefixedCustHash(curve, sig, pubKey, curve.HashToG1, parts...)
+}
+
+// VerifyLengthPrefixedCustHash checks that sig is a length prefixed signature
+// by pubKey on the message made of parts, using a supplied function to hash to g1.
+func VerifyLengthPrefixedCustHash(curve CurveSystem, sig Point, pubKey Point,
+	hash func([]byte) Point, parts ...[]byte) bool {
+	return VerifySingleSignatureCustHash(curve, sig, pubKey, lengthPrefixedMessage(lengthPrefixedTag, parts), hash)
+}
+
+// SignFields creates a signature on a tuple of fields with a private key, e.g. a
+// structured message's (slot, root, index). The fields are length prefixed as in
+// SignLengthPrefixed, after a tag and the domain, so the signature binds both
+// the boundaries between fields and the domain it is for.
+func SignFields(curve CurveSystem, sk *big.Int, domain [8]byte, fields ...[]byte) Point {
+	return SignCustHash(sk, fieldsMessage(domain, fields), curve.HashToG1)
+}
+
+// VerifyFields checks that sig is a signature created by SignFields with pubKey's
+// secret key, on the fields in the domain.
+func VerifyFields(curve CurveSystem, sig Point, pubKey Point, domain [8]byte, fields ...[]byte) bool {
+	return VerifySingleSignature(curve, sig, pubKey, fieldsMessage(domain, fields))
+}
+
+// fieldsMessage returns the message which is signed for the fields in the domain.
+func fieldsMessage(domain [8]byte, fields [][]byte) []byte {
+	prefix := append(append([]byte{}, fieldsTag...), domain[:]...)
+	return lengthPrefixedMessage(prefix, fields)
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLengthPrefixedSignatures(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		// Both splits concatenate to "abc", so plain signatures on them are equal.
+		assert.True(t, Sign(curve, sk, append([]byte("ab"), 'c')).Equals(Sign(curve, sk, append([]byte("a"), "bc"...))))
+
+		sig1 := SignLengthPrefixed(curve, sk, []byte("ab"), []byte("c"))
+		sig2 := SignLengthPrefixed(curve, sk, []byte("a"), []byte("bc"))
+		assert.False(t, sig1.Equals(sig2), "Differently split messages have the same signature")
+		assert.True(t, VerifyLengthPrefixed(curve, sig1, vk, []byte("ab"), []byte("c")),
+			"Length prefixed signature verification failed")
+		assert.True(t, VerifyLengthPrefixed(curve, sig2, vk, []byte("a"), []byte("bc")),
+			"Length prefixed signature verification failed")
+		assert.False(t, VerifyLengthPrefixed(curve, sig1, vk, []byte("a"), []byte("bc")),
+			"Length prefixed signature verified with a different split")
+		assert.False(t, VerifyLengthPrefixed(curve, sig1, vk, []byte("abc")),
+			"Length prefixed signature verified with the parts joined")
+		assert.False(t, VerifySingleSignature(curve, sig1, vk, []byte("abc")),
+			"Length prefixed signature verified as a plain signature")
+
+		empty := SignLengthPrefixed(curve, sk)
+		assert.True(t, VerifyLengthPrefixed(curve, empty, vk))
+		assert.False(t, VerifyLengthPrefixed(curve, empty, vk, []byte{}), "No parts verified as one empty part")
+	}
+}
+
+func TestSignFields(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		domain := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+		slot, root, index := []byte{0, 0, 0, 9}, []byte("root"), []byte{1}
+
+		sig := SignFields(curve, sk, domain, slot, root, index)
+		assert.True(t, VerifyFields(curve, sig, vk, domain, slot, root, index), "Fields signature verification failed")
+		regrouped := SignFields(curve, sk, domain, append(append([]byte{}, slot...), root...), index)
+		assert.False(t, sig.Equals(regrouped), "Differently grouped fields have the same signature")
+		assert.False(t, VerifyFields(curve, sig, vk, domain, append(append([]byte{}, slot...), root...), index),
+			"Fields signature verified with the fields regrouped")
+
+		otherDomain := domain
+		otherDomain[0] = 0
+		assert.False(t, sig.Equals(SignFields(curve, sk, otherDomain, slot, root, index)),
+			"Fields in different domains have the same signature")
+		assert.False(t, VerifyFields(curve, sig, vk, otherDomain, slot, root, index),
+			"Fields signature verified in a different domain")
+		assert.False(t, VerifyLengthPrefixed(curve, sig, vk, slot, root, index),
+			"Fields signature verified as a length prefixed signature")
+	}
+}
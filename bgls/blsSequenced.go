@@ -0,0 +1,55 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements sequenced signatures, which bind a sequence number into
+// the signed message to prevent replay. The message that is actually signed is
+// a fixed domain tag, followed by the sequence number as 8 big endian bytes,
+// followed by the message. As the tag and sequence number have a fixed width,
+// no two (seq, msg) pairs produce the same signed message.
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// sequencedTag is prepended to sequenced messages before signing.
+var sequencedTag = []byte("BGLS_SEQUENCED")
+
+// sequencedMessage returns the message which is signed for msg at seq.
+func sequencedMessage(msg []byte, seq uint64) []byte {
+	m := make([]byte, len(sequencedTag)+8, len(sequencedTag)+8+len(msg))
+	copy(m, sequencedTag)
+	binary.BigEndian.PutUint64(m[len(sequencedTag):], seq)
+	return append(m, msg...)
+}
+
+// SignSequenced creates a signature on a message with a private key, binding
+// the sequence number seq into the signature.
+func SignSequenced(curve CurveSystem, sk *big.Int, msg []byte, seq uint64) Point {
+	return SignSequencedCustHash(sk, msg, seq, curve.HashToG1)
+}
+
+// SignSequencedCustHash creates a signature on a message with a private key,
+// binding the sequence number seq into the signature, using a supplied function
+// to hash to g1.
+func SignSequencedCustHash(sk *big.Int, msg []byte, seq uint64, hash func([]byte) Point) Point {
+	return SignCustHash(sk, sequencedMessage(msg, seq), hash)
+}
+
+// VerifySequenced checks that sig is a sequenced signature on msg by pubKey,
+// with the expected sequence number seq.
+func VerifySequenced(curve CurveSystem, sig Point, pubKey Point, msg []byte, seq uint64) bool {
+	return VerifySequencedCustHash(curve, sig, pubKey, msg, seq, curve.HashToG1)
+}
+
+// VerifySequencedCustHash checks that sig is a sequenced signature on msg by
+// pubKey, with the expected sequence number seq, using a supplied function to
+// hash to g1.
+func VerifySequencedCustHash(curve CurveSystem, sig Point, pubKey Point, msg []byte,
+	seq uint64, hash func([]byte) Point) bool {
+	return VerifySingleSignatureCustHash(curve, sig, pubKey, sequencedMessage(msg, seq), hash)
+}
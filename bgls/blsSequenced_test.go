@@ -0,0 +1,31 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequencedSignatures(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		sig1 := SignSequenced(curve, sk, msg, 1)
+		sig2 := SignSequenced(curve, sk, msg, 2)
+		assert.True(t, VerifySequenced(curve, sig1, vk, msg, 1), "Sequenced signature verification failed")
+		assert.True(t, VerifySequenced(curve, sig2, vk, msg, 2), "Sequenced signature verification failed")
+		assert.False(t, VerifySequenced(curve, sig1, vk, msg, 2), "Sequenced signature verified with the wrong seq")
+		assert.False(t, VerifySequenced(curve, sig2, vk, msg, 1), "Sequenced signature verified with the wrong seq")
+		assert.False(t, sig1.Equals(sig2))
+		assert.False(t, VerifySingleSignature(curve, sig1, vk, msg), "Sequenced signature verified as a plain signature")
+
+		sigLarge := SignSequenced(curve, sk, msg, 1<<40)
+		assert.True(t, VerifySequenced(curve, sigLarge, vk, msg, 1<<40))
+		assert.False(t, VerifySequenced(curve, sigLarge, vk, msg, 0))
+	}
+}
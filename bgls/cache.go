@@ -0,0 +1,92 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// VerificationCache remembers standard BLS signatures which have verified, so
+// that verifying the same signature again costs a hash rather than pairings.
+// Only successful verifications are cached, so an invalid signature is checked
+// again every time, and can't evict valid ones by failing. Once full, the least
+// recently used entry is evicted. It is safe for concurrent use.
+type VerificationCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[[32]byte]*list.Element
+	order   *list.List
+}
+
+// NewVerificationCache creates a VerificationCache holding up to size
+// verified signatures. A size less than 1 is treated as 1.
+func NewVerificationCache(size int) *VerificationCache {
+	if size < 1 {
+		size = 1
+	}
+	return &VerificationCache{
+		size:    size,
+		entries: make(map[[32]byte]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// CachedVerify checks that sig is a standard BLS signature on msg by pubKey, as
+// VerifySingleSignature does, unless the same signature has already verified,
+// in which case it returns true without any pairings.
+func (c *VerificationCache) CachedVerify(curve CurveSystem, pubKey Point, msg []byte, sig Point) bool {
+	requireCurve(curve, "CachedVerify")
+	if CheckCurve(curve, pubKey, sig) != nil {
+		return false
+	}
+	key := verificationCacheKey(curve, pubKey, msg, sig)
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		c.mu.Unlock()
+		return true
+	}
+	c.mu.Unlock()
+
+	if !VerifySingleSignature(curve, sig, pubKey, msg) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.order.PushFront(key)
+		if c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.([32]byte))
+		}
+	}
+	return true
+}
+
+// Len returns the number of verified signatures in the cache.
+func (c *VerificationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// verificationCacheKey is sha256 of the curve's name, the compressed public key,
+// the message and the compressed signature. The points have fixed sizes, so the
+// encoding is unambiguous.
+func verificationCacheKey(curve CurveSystem, pubKey Point, msg []byte, sig Point) [32]byte {
+	h := sha256.New()
+	h.Write([]byte(curve.Name()))
+	h.Write([]byte{0})
+	h.Write(pubKey.Marshal())
+	h.Write(msg)
+	h.Write(sig.Marshal())
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
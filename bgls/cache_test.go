@@ -0,0 +1,45 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationCache(t *testing.T) {
+	for _, curve := range curves {
+		cache := NewVerificationCache(2)
+		counter := &pairingCounter{CurveSystem: curve}
+		sk, vk, _ := KeyGen(curve)
+		msgs := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+		sigs := make([]Point, len(msgs))
+		for i := 0; i < len(msgs); i++ {
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+
+		assert.True(t, cache.CachedVerify(counter, vk, msgs[0], sigs[0]), "Valid signature failed verification")
+		pairings := counter.pairings
+		assert.True(t, pairings > 0, "First verification didn't compute pairings")
+		assert.True(t, cache.CachedVerify(counter, vk, msgs[0], sigs[0]), "Cached signature failed verification")
+		assert.Equal(t, pairings, counter.pairings, "Cache hit recomputed pairings")
+
+		assert.False(t, cache.CachedVerify(counter, vk, msgs[1], sigs[0]), "Invalid signature verified")
+		assert.False(t, cache.CachedVerify(counter, vk, msgs[1], sigs[0]), "Invalid signature verified")
+		assert.Equal(t, 1, cache.Len(), "Invalid signature was cached")
+
+		// The least recently used signature is evicted
+		assert.True(t, cache.CachedVerify(counter, vk, msgs[1], sigs[1]))
+		assert.True(t, cache.CachedVerify(counter, vk, msgs[0], sigs[0]))
+		assert.True(t, cache.CachedVerify(counter, vk, msgs[2], sigs[2]))
+		assert.Equal(t, 2, cache.Len())
+		pairings = counter.pairings
+		assert.True(t, cache.CachedVerify(counter, vk, msgs[0], sigs[0]))
+		assert.Equal(t, pairings, counter.pairings, "Recently used signature was evicted")
+		assert.True(t, cache.CachedVerify(counter, vk, msgs[1], sigs[1]))
+		assert.True(t, counter.pairings > pairings, "Least recently used signature wasn't evicted")
+	}
+}
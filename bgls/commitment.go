@@ -0,0 +1,38 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements a commit-reveal protocol built on standard BLS
+// signatures. The committer publishes Commit(msg), the hash of msg to G1, which
+// binds them to msg. A standard BLS signature on msg is sk times the
+// commitment, so it opens the commitment: anyone can check with VerifyOpening
+// that the committer's key has signed the committed point, without knowing msg.
+// Revealing msg then lets anyone check that it hashes to the commitment.
+// Commitments to low entropy messages can be opened by guessing, so such
+// messages should have a random nonce appended before committing.
+
+import (
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// Commit returns the commitment to msg, which is its hash to G1.
+func Commit(curve CurveSystem, msg []byte) Point {
+	requireCurve(curve, "Commit")
+	return curve.HashToG1(msg)
+}
+
+// VerifyOpening checks that sig opens the commitment for pubKey, i.e. that sig is
+// pubKey's standard BLS signature on the committed message. It fails for the
+// identity commitment, which any identity signature would open.
+func VerifyOpening(curve CurveSystem, commitment Point, pubKey Point, sig Point) bool {
+	requireCurve(curve, "VerifyOpening")
+	if CheckCurve(curve, commitment, pubKey, sig) != nil || commitment.Equals(curve.GetG1Infinity()) {
+		return false
+	}
+	negCommitment := commitment.Mul(new(big.Int).SetInt64(-1))
+	paired, ok := curve.PairingProduct([]Point{negCommitment, sig}, []Point{pubKey, curve.GetG2()})
+	return ok && paired.Equals(curve.GetGTIdentity())
+}
@@ -0,0 +1,30 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommit(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := []byte("sealed bid")
+		commitment := Commit(curve, msg)
+		assert.True(t, commitment.Equals(Commit(curve, msg)), "Commitment isn't deterministic")
+		assert.False(t, commitment.Equals(Commit(curve, []byte("other bid"))), "Different messages have the same commitment")
+
+		opening := Sign(curve, sk, msg)
+		assert.True(t, VerifyOpening(curve, commitment, vk, opening), "Signature failed to open the commitment")
+		assert.True(t, VerifySingleSignature(curve, opening, vk, msg), "Opening isn't a signature on the message")
+		assert.False(t, VerifyOpening(curve, Commit(curve, []byte("other bid")), vk, opening),
+			"Signature opened a different commitment")
+		_, otherVk, _ := KeyGen(curve)
+		assert.False(t, VerifyOpening(curve, commitment, otherVk, opening), "Signature opened the commitment for another key")
+		assert.False(t, VerifyOpening(curve, curve.GetG1Infinity(), vk, curve.GetG1Infinity()),
+			"Identity signature opened the identity commitment")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements verification with points given as raw affine coordinates,
+// which is how points are passed to and from the EVM contracts.
+
+import (
+	"errors"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// ErrInvalidCoordinates is returned when coordinates don't describe a point in
+// the expected group.
+var ErrInvalidCoordinates = errors.New("coordinates are not a valid point in the group")
+
+// VerifyFromCoordinates verifies a standard BLS signature, where the public key
+// is given by its affine coordinates [x0, x1, y0, y1] as in MakeG2Point, and the
+// signature by its affine coordinates [x, y]. An error is returned if either set
+// of coordinates isn't a point in the correct subgroup, or is the identity.
+func VerifyFromCoordinates(curve CurveSystem, pubKeyXY [4]*big.Int, msg []byte, sigXY [2]*big.Int) (bool, error) {
+	pubKey, err := pointFromCoordinates(curve.MakeG2Point, curve.GetG2Infinity(), pubKeyXY[:])
+	if err != nil {
+		return false, err
+	}
+	sig, err := pointFromCoordinates(curve.MakeG1Point, curve.GetG1Infinity(), sigXY[:])
+	if err != nil {
+		return false, err
+	}
+	return VerifySingleSignature(curve, sig, pubKey, msg), nil
+}
+
+// pointFromCoordinates makes a point from its coordinates, rejecting the identity,
+// since an identity key and signature verify for any message.
+func pointFromCoordinates(makePoint func([]*big.Int, bool) (Point, bool), identity Point,
+	coords []*big.Int) (Point, error) {
+	for i := 0; i < len(coords); i++ {
+		if coords[i] == nil || coords[i].Sign() < 0 {
+			return nil, ErrInvalidCoordinates
+		}
+	}
+	pt, ok := makePoint(coords, true)
+	if !ok || !pt.InCorrectSubgroup() || pt.Equals(identity) {
+		return nil, ErrInvalidCoordinates
+	}
+	return pt, nil
+}
+
+// G1Coordinates returns the affine coordinates of a point in G1, as taken by
+// MakeG1Point and VerifyFromCoordinates. Both are nil if pt isn't in G1.
+func G1Coordinates(pt Point) (x, y *big.Int) {
+	coords := pt.ToAffineCoords()
+	if len(coords) != 2 {
+		return nil, nil
+	}
+	return coords[0], coords[1]
+}
+
+// G2Coordinates returns the affine coordinates of a point in G2, where
+// X = x0 * i + x1 and Y = y0 * i + y1, as taken by MakeG2Point and
+// VerifyFromCoordinates. All are nil if pt isn't in G2.
+func G2Coordinates(pt Point) (x0, x1, y0, y1 *big.Int) {
+	coords := pt.ToAffineCoords()
+	if len(coords) != 4 {
+		return nil, nil, nil, nil
+	}
+	return coords[0], coords[1], coords[2], coords[3]
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyFromCoordinates(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		sig := Sign(curve, sk, msg)
+
+		var pubKeyXY [4]*big.Int
+		var sigXY [2]*big.Int
+		copy(pubKeyXY[:], vk.ToAffineCoords())
+		copy(sigXY[:], sig.ToAffineCoords())
+		ok, err := VerifyFromCoordinates(curve, pubKeyXY, msg, sigXY)
+		assert.Nil(t, err)
+		assert.True(t, ok, "Verification from coordinates failed")
+
+		msg2 := make([]byte, 64)
+		rand.Read(msg2)
+		ok, err = VerifyFromCoordinates(curve, pubKeyXY, msg2, sigXY)
+		assert.Nil(t, err)
+		assert.False(t, ok, "Verification from coordinates succeeded on the wrong message")
+
+		badSigXY := sigXY
+		badSigXY[1] = new(big.Int).Add(sigXY[1], big.NewInt(1))
+		_, err = VerifyFromCoordinates(curve, pubKeyXY, msg, badSigXY)
+		assert.Equal(t, ErrInvalidCoordinates, err, "Off curve signature didn't error")
+		badSigXY[1] = new(big.Int).Add(sigXY[1], curve.GetG1Q())
+		_, err = VerifyFromCoordinates(curve, pubKeyXY, msg, badSigXY)
+		assert.Equal(t, ErrInvalidCoordinates, err, "Unreduced signature coordinate didn't error")
+		badPubKeyXY := pubKeyXY
+		badPubKeyXY[3] = new(big.Int).Add(pubKeyXY[3], big.NewInt(1))
+		_, err = VerifyFromCoordinates(curve, badPubKeyXY, msg, sigXY)
+		assert.Equal(t, ErrInvalidCoordinates, err, "Off curve public key didn't error")
+		zeroPubKeyXY := [4]*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)}
+		zeroSigXY := [2]*big.Int{big.NewInt(0), big.NewInt(0)}
+		ok, err = VerifyFromCoordinates(curve, zeroPubKeyXY, msg2, zeroSigXY)
+		assert.Equal(t, ErrInvalidCoordinates, err, "Identity key and signature didn't error")
+		assert.False(t, ok, "Identity key and signature verified")
+		_, err = VerifyFromCoordinates(curve, pubKeyXY, msg, zeroSigXY)
+		assert.Equal(t, ErrInvalidCoordinates, err, "Identity signature didn't error")
+		_, err = VerifyFromCoordinates(curve, zeroPubKeyXY, msg, sigXY)
+		assert.Equal(t, ErrInvalidCoordinates, err, "Identity public key didn't error")
+		badPubKeyXY[3] = nil
+		_, err = VerifyFromCoordinates(curve, badPubKeyXY, msg, sigXY)
+		assert.Equal(t, ErrInvalidCoordinates, err, "Missing public key coordinate didn't error")
+	}
+}
+
+func TestCoordinates(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		sig := Sign(curve, sk, []byte("coordinates"))
+
+		x, y := G1Coordinates(sig)
+		remade, ok := curve.MakeG1Point([]*big.Int{x, y}, true)
+		assert.True(t, ok && remade.Equals(sig), "G1 point not reconstructed from its coordinates")
+		x0, x1, y0, y1 := G2Coordinates(vk)
+		remade, ok = curve.MakeG2Point([]*big.Int{x0, x1, y0, y1}, true)
+		assert.True(t, ok && remade.Equals(vk), "G2 point not reconstructed from its coordinates")
+
+		x, y = G1Coordinates(vk)
+		assert.True(t, x == nil && y == nil, "G1 coordinates returned for a G2 point")
+		x0, _, _, _ = G2Coordinates(sig)
+		assert.Nil(t, x0, "G2 coordinates returned for a G1 point")
+	}
+}
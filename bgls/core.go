@@ -0,0 +1,66 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements the core operations of the IETF BLS signature draft
+// (https://datatracker.ietf.org/doc/draft-irtf-cfrg-bls-signature/), which take
+// the hash to G1 and the domain separation tag explicitly. A ciphersuite fixes
+// both, e.g. the registered domains in domain.go hash with HashToG1WithHash over
+// SHA-256 and the domain's tag. As in the draft, CoreAggregateVerify doesn't
+// require the messages to be distinct, and leaves that to the ciphersuite.
+
+import (
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// CoreSign creates a BLS signature on a message with a private key, hashing the
+// message onto G1 with hash and the domain separation tag dst.
+func CoreSign(sk *big.Int, msg []byte, dst []byte, hash func(msg []byte, dst []byte) Point) Point {
+	return SignCustHash(sk, msg, bindDST(dst, hash))
+}
+
+// CoreVerify checks that sig is a signature on msg by pubKey, hashing the
+// message onto G1 with hash and the domain separation tag dst. As in the draft,
+// this fails if the public key is the identity, or either point is outside its
+// subgroup.
+func CoreVerify(curve CurveSystem, pubKey Point, msg []byte, sig Point, dst []byte,
+	hash func(msg []byte, dst []byte) Point) bool {
+	requireCurve(curve, "CoreVerify")
+	if !validCoreKey(curve, pubKey) || sig == nil || !sig.InCorrectSubgroup() {
+		return false
+	}
+	return VerifySingleSignatureCustHash(curve, sig, pubKey, msg, bindDST(dst, hash))
+}
+
+// CoreAggregateVerify checks that sig is an aggregate of a signature on each of
+// msgs[i] by pubKeys[i], hashing the messages onto G1 with hash and the domain
+// separation tag dst. Duplicate messages are allowed, so the ciphersuite must
+// protect against the rogue public key attack.
+func CoreAggregateVerify(curve CurveSystem, pubKeys []Point, msgs [][]byte, sig Point, dst []byte,
+	hash func(msg []byte, dst []byte) Point) bool {
+	requireCurve(curve, "CoreAggregateVerify")
+	for _, pubKey := range pubKeys {
+		if !validCoreKey(curve, pubKey) {
+			return false
+		}
+	}
+	if sig == nil || !sig.InCorrectSubgroup() {
+		return false
+	}
+	return verifyAggSigCustHash(curve, sig, pubKeys, msgs, true, bindDST(dst, hash))
+}
+
+// validCoreKey checks a public key as the draft's KeyValidate does.
+func validCoreKey(curve CurveSystem, pubKey Point) bool {
+	return pubKey != nil && !pubKey.Equals(curve.GetG2Infinity()) && pubKey.InCorrectSubgroup()
+}
+
+// bindDST returns the hash to G1 with the domain separation tag fixed to dst.
+func bindDST(dst []byte, hash func(msg []byte, dst []byte) Point) func([]byte) Point {
+	return func(msg []byte) Point {
+		return hash(msg, dst)
+	}
+}
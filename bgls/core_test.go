@@ -0,0 +1,68 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoreSignVerify(t *testing.T) {
+	dstA := []byte("BGLS_TEST_CORE_A")
+	dstB := []byte("BGLS_TEST_CORE_B")
+	for _, curve := range curves {
+		hash := func(msg []byte, dst []byte) Point { return curve.HashToG1WithHash(msg, dst, sha256.New) }
+		sk, vk, _ := KeyGen(curve)
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		sig := CoreSign(sk, msg, dstA, hash)
+		assert.True(t, sig.Equals(curve.HashToG1WithHash(msg, dstA, sha256.New).Mul(sk)))
+		assert.True(t, CoreVerify(curve, vk, msg, sig, dstA, hash), "Core signature failed verification")
+		assert.False(t, CoreVerify(curve, vk, msg, sig, dstB, hash), "Core signature passed with another tag")
+		assert.False(t, CoreVerify(curve, vk, msg[1:], sig, dstA, hash), "Core signature passed on another message")
+		assert.False(t, CoreVerify(curve, curve.GetG2Infinity(), msg, curve.GetG1Infinity(), dstA, hash),
+			"Identity key passed core verification")
+
+		// The registered domains are a ciphersuite of the core operations
+		assert.Nil(t, RegisterDomain(7, dstA))
+		domainSig, _ := SignDomainID(curve, sk, msg, 7)
+		assert.True(t, domainSig.Equals(sig), "Domain signature differs from the core signature")
+	}
+}
+
+func TestCoreAggregateVerify(t *testing.T) {
+	dst := []byte("BGLS_TEST_CORE_AGGREGATE")
+	for _, curve := range curves {
+		hash := func(msg []byte, dst []byte) Point { return curve.HashToG1WithHash(msg, dst, sha256.New) }
+		N := 5
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+			// The core operations allow duplicate messages
+			if i == N-1 {
+				msgs[i] = msgs[0]
+			}
+			sigs[i] = CoreSign(sk, msgs[i], dst, hash)
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, CoreAggregateVerify(curve, keys, msgs, aggsig, dst, hash),
+			"Core aggregate signature failed verification")
+		assert.False(t, CoreAggregateVerify(curve, keys, msgs, aggsig, []byte("BGLS_OTHER"), hash),
+			"Core aggregate signature passed with another tag")
+		assert.False(t, CoreAggregateVerify(curve, keys[1:], msgs[1:], aggsig, dst, hash),
+			"Core aggregate signature passed with a missing signer")
+		keys[1] = curve.GetG2Infinity()
+		assert.False(t, CoreAggregateVerify(curve, keys, msgs, aggsig, dst, hash),
+			"Core aggregate signature passed with an identity key")
+	}
+}
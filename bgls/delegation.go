@@ -0,0 +1,51 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// delegationTag is prepended to the delegate's key in the message a delegation
+// signs, so that a delegation can't be confused with a signature on other data.
+var delegationTag = []byte("BGLS_DELEGATION")
+
+// DelegationLink is one link of a delegation chain, in which the previous key
+// in the chain delegates to Delegate by signing it.
+type DelegationLink struct {
+	Delegate  Point
+	Signature Point
+}
+
+// Delegate creates the link from the key of sk to delegate.
+func Delegate(curve CurveSystem, sk *big.Int, delegate Point) DelegationLink {
+	return DelegationLink{Delegate: delegate, Signature: Sign(curve, sk, delegationMessage(delegate))}
+}
+
+// VerifyDelegationChain checks that rootKey delegated to the first link's
+// delegate, which delegated to the second's, and so on, so that the chain
+// delegates from rootKey to the last link's delegate. An empty chain, or any
+// invalid link, fails.
+func VerifyDelegationChain(curve CurveSystem, rootKey Point, links []DelegationLink) bool {
+	requireCurve(curve, "VerifyDelegationChain")
+	if len(links) == 0 {
+		return false
+	}
+	delegator := rootKey
+	for _, link := range links {
+		if link.Delegate == nil || link.Signature == nil ||
+			!VerifySingleSignature(curve, link.Signature, delegator, delegationMessage(link.Delegate)) {
+			return false
+		}
+		delegator = link.Delegate
+	}
+	return true
+}
+
+// delegationMessage is the message signed by a delegation to delegate.
+func delegationMessage(delegate Point) []byte {
+	return append(append([]byte{}, delegationTag...), delegate.Marshal()...)
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyDelegationChain(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		sks := make([]*big.Int, N)
+		vks := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sks[i], vks[i], _ = KeyGen(curve)
+		}
+		links := make([]DelegationLink, N-1)
+		for i := 0; i < N-1; i++ {
+			links[i] = Delegate(curve, sks[i], vks[i+1])
+		}
+		assert.True(t, VerifyDelegationChain(curve, vks[0], links), "Valid delegation chain failed verification")
+		assert.False(t, VerifyDelegationChain(curve, vks[1], links), "Delegation chain verified from the wrong root")
+		assert.False(t, VerifyDelegationChain(curve, vks[0], nil), "Empty delegation chain verified")
+
+		_, other, _ := KeyGen(curve)
+		for i := 0; i < N-1; i++ {
+			tampered := append([]DelegationLink{}, links...)
+			tampered[i].Delegate = other
+			assert.False(t, VerifyDelegationChain(curve, vks[0], tampered), "Chain with a replaced delegate verified")
+			tampered = append([]DelegationLink{}, links...)
+			tampered[i].Signature, _ = tampered[i].Signature.Add(curve.GetG1())
+			assert.False(t, VerifyDelegationChain(curve, vks[0], tampered), "Chain with a tampered signature verified")
+		}
+		// A link signed by the wrong delegator
+		skipped := []DelegationLink{links[0], Delegate(curve, sks[0], vks[2]), links[2]}
+		assert.False(t, VerifyDelegationChain(curve, vks[0], skipped), "Chain with a link from the wrong key verified")
+		// A plain signature on the delegate's key isn't a delegation
+		plain := []DelegationLink{{Delegate: vks[1], Signature: Sign(curve, sks[0], vks[1].Marshal())}}
+		assert.False(t, VerifyDelegationChain(curve, vks[0], plain), "Untagged signature verified as a delegation")
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// digestTag is prepended to a digest before hashing it to the curve, so that a
+// signature on a digest is never a signature on a raw message.
+var digestTag = []byte("BGLS_DIGEST")
+
+// SignDigest creates a BLS signature on a 32 byte message digest with a private
+// key, e.g. for peers which only send digests of the messages they aggregate.
+func SignDigest(curve CurveSystem, sk *big.Int, digest [32]byte) Point {
+	return Sign(curve, sk, digestMessage(digest))
+}
+
+// VerifyAggregateAgainstDigest checks that aggsig is an aggregate of signatures
+// created by SignDigest, where msgDigests[i] was signed by keys[i]. As with
+// VerifyAggregateSignature, the digests must be distinct.
+func VerifyAggregateAgainstDigest(curve CurveSystem, aggsig Point, keys []Point, msgDigests [][32]byte) bool {
+	requireCurve(curve, "VerifyAggregateAgainstDigest")
+	msgs := make([][]byte, len(msgDigests))
+	for i := 0; i < len(msgDigests); i++ {
+		msgs[i] = digestMessage(msgDigests[i])
+	}
+	return verifyAggSig(curve, aggsig, keys, msgs, false)
+}
+
+// digestMessage returns the message which is signed for the digest.
+func digestMessage(digest [32]byte) []byte {
+	return append(append([]byte{}, digestTag...), digest[:]...)
+}
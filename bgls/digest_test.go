@@ -0,0 +1,46 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyAggregateAgainstDigest(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 4, 64
+		keys := make([]Point, N)
+		sigs := make([]Point, N)
+		msgs := make([][]byte, N)
+		digests := make([][32]byte, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			digests[i] = sha256.Sum256(msgs[i])
+			sigs[i] = SignDigest(curve, sk, digests[i])
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateAgainstDigest(curve, aggsig, keys, digests),
+			"Aggregate of digest signatures failed verification")
+		assert.False(t, VerifyAggregateSignature(curve, aggsig, keys, msgs),
+			"Aggregate of digest signatures verified against the raw messages")
+		wrong := append([][32]byte{}, digests...)
+		wrong[1][0] ^= 1
+		assert.False(t, VerifyAggregateAgainstDigest(curve, aggsig, keys, wrong), "Aggregate verified with a wrong digest")
+		assert.False(t, VerifyAggregateAgainstDigest(curve, aggsig, keys[1:], digests), "Aggregate verified with a missing key")
+
+		// Plain signatures on the raw digests don't verify against them
+		sk, vk, _ := KeyGen(curve)
+		plain := Sign(curve, sk, digests[0][:])
+		assert.False(t, VerifyAggregateAgainstDigest(curve, plain, []Point{vk}, digests[:1]),
+			"Plain signature on a digest verified as a digest signature")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements signing with registered domains. A domain separation tag
+// is registered once under a small id, and messages are then signed and verified
+// by referencing the id. Messages are hashed to G1 with
+// CurveSystem.HashToG1WithHash, using SHA-256 and the domain's tag, which makes
+// this a ciphersuite of the core operations in core.go.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// ErrUnknownDomain is returned when signing or verifying with an unregistered domain id.
+var ErrUnknownDomain = errors.New("domain id isn't registered")
+
+// ErrDomainRegistered is returned when registering a domain id which is already
+// registered with a different tag.
+var ErrDomainRegistered = errors.New("domain id is already registered with a different tag")
+
+var domains = struct {
+	sync.RWMutex
+	tags map[uint16][]byte
+}{tags: make(map[uint16][]byte)}
+
+// RegisterDomain registers the domain separation tag dst under id. Registering
+// the same tag under an id again has no effect.
+func RegisterDomain(id uint16, dst []byte) error {
+	domains.Lock()
+	defer domains.Unlock()
+	if existing, ok := domains.tags[id]; ok {
+		if bytes.Equal(existing, dst) {
+			return nil
+		}
+		return ErrDomainRegistered
+	}
+	domains.tags[id] = append([]byte{}, dst...)
+	return nil
+}
+
+// domainTag returns the domain separation tag registered under id.
+func domainTag(id uint16) ([]byte, error) {
+	domains.RLock()
+	dst, ok := domains.tags[id]
+	domains.RUnlock()
+	if !ok {
+		return nil, ErrUnknownDomain
+	}
+	return dst, nil
+}
+
+// domainHash hashes a message to G1 with SHA-256 and the domain separation tag.
+func domainHash(curve CurveSystem) func(msg []byte, dst []byte) Point {
+	return func(msg []byte, dst []byte) Point {
+		return curve.HashToG1WithHash(msg, dst, sha256.New)
+	}
+}
+
+// SignDomainID creates a standard BLS signature on a message with a private key,
+// in the domain registered under id.
+func SignDomainID(curve CurveSystem, sk *big.Int, msg []byte, id uint16) (Point, error) {
+	dst, err := domainTag(id)
+	if err != nil {
+		return nil, err
+	}
+	return CoreSign(sk, msg, dst, domainHash(curve)), nil
+}
+
+// VerifyDomainID checks that sig is a signature on msg by pubKey, in the domain
+// registered under id.
+func VerifyDomainID(curve CurveSystem, sig Point, pubKey Point, msg []byte, id uint16) (bool, error) {
+	dst, err := domainTag(id)
+	if err != nil {
+		return false, err
+	}
+	return CoreVerify(curve, pubKey, msg, sig, dst, domainHash(curve)), nil
+}
+
+// SignEpochDST creates a standard BLS signature on a message with a private key,
+// in a domain which rotates every epoch. The domain separation tag is dstPrefix
+// followed by the epoch as 8 big endian bytes, so a signature from one epoch
+// doesn't verify in any other.
+func SignEpochDST(curve CurveSystem, sk *big.Int, msg []byte, epoch uint64, dstPrefix []byte) Point {
+	return CoreSign(sk, msg, epochDST(dstPrefix, epoch), domainHash(curve))
+}
+
+// VerifyEpochDST checks that sig is a signature created by SignEpochDST on msg by
+// pubKey, in the epoch.
+func VerifyEpochDST(curve CurveSystem, sig Point, pubKey Point, msg []byte, epoch uint64, dstPrefix []byte) bool {
+	return CoreVerify(curve, pubKey, msg, sig, epochDST(dstPrefix, epoch), domainHash(curve))
+}
+
+// epochDST returns the domain separation tag for the epoch.
+func epochDST(dstPrefix []byte, epoch uint64) []byte {
+	dst := make([]byte, len(dstPrefix)+8)
+	copy(dst, dstPrefix)
+	binary.BigEndian.PutUint64(dst[len(dstPrefix):], epoch)
+	return dst
+}
+
+// nonceDSTPrefix is followed by the nonce in the domain separation tags of
+// SignWithNonce.
+var nonceDSTPrefix = []byte("BGLS_NONCE_")
+
+// SignWithNonce creates a standard BLS signature on a message with a private key,
+// bound to a nonce, e.g. one chosen by a server for each session, so that the
+// signature can't be replayed in another session. The nonce is part of the
+// domain separation tag rather than the message.
+func SignWithNonce(curve CurveSystem, sk *big.Int, msg []byte, nonce []byte) Point {
+	return CoreSign(sk, msg, nonceDST(nonce), domainHash(curve))
+}
+
+// VerifyWithNonce checks that sig is a signature created by SignWithNonce on msg
+// by pubKey, with the expected nonce.
+func VerifyWithNonce(curve CurveSystem, sig Point, pubKey Point, msg []byte, nonce []byte) bool {
+	return CoreVerify(curve, pubKey, msg, sig, nonceDST(nonce), domainHash(curve))
+}
+
+// nonceDST returns the domain separation tag for the nonce.
+func nonceDST(nonce []byte) []byte {
+	return append(append([]byte{}, nonceDSTPrefix...), nonce...)
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainID(t *testing.T) {
+	assert.Nil(t, RegisterDomain(1, []byte("BGLS_TEST_DOMAIN_A")))
+	assert.Nil(t, RegisterDomain(2, []byte("BGLS_TEST_DOMAIN_B")))
+	assert.Nil(t, RegisterDomain(1, []byte("BGLS_TEST_DOMAIN_A")), "Registering the same domain twice failed")
+	assert.Equal(t, ErrDomainRegistered, RegisterDomain(1, []byte("BGLS_TEST_DOMAIN_C")))
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		sigA, err := SignDomainID(curve, sk, msg, 1)
+		assert.Nil(t, err)
+		sigB, err := SignDomainID(curve, sk, msg, 2)
+		assert.Nil(t, err)
+
+		ok, err := VerifyDomainID(curve, sigA, vk, msg, 1)
+		assert.True(t, ok && err == nil, "Domain signature verification failed")
+		ok, err = VerifyDomainID(curve, sigB, vk, msg, 2)
+		assert.True(t, ok && err == nil, "Domain signature verification failed")
+		ok, _ = VerifyDomainID(curve, sigA, vk, msg, 2)
+		assert.False(t, ok, "Signature verified in another domain")
+		ok, _ = VerifyDomainID(curve, sigB, vk, msg, 1)
+		assert.False(t, ok, "Signature verified in another domain")
+
+		_, err = SignDomainID(curve, sk, msg, 999)
+		assert.Equal(t, ErrUnknownDomain, err)
+		_, err = VerifyDomainID(curve, sigA, vk, msg, 999)
+		assert.Equal(t, ErrUnknownDomain, err)
+	}
+}
+
+func TestEpochDST(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := []byte("rotating")
+		prefix := []byte("BGLS_EPOCH_TEST_")
+		sig := SignEpochDST(curve, sk, msg, 7, prefix)
+		assert.True(t, VerifyEpochDST(curve, sig, vk, msg, 7, prefix), "Epoch signature failed verification")
+		assert.False(t, VerifyEpochDST(curve, sig, vk, msg, 8, prefix), "Epoch signature verified in the next epoch")
+		assert.False(t, VerifyEpochDST(curve, sig, vk, msg, 6, prefix), "Epoch signature verified in the previous epoch")
+		assert.False(t, sig.Equals(SignEpochDST(curve, sk, msg, 8, prefix)), "Epochs have the same signature")
+		assert.False(t, VerifyEpochDST(curve, sig, vk, msg, 7, []byte("OTHER_PREFIX")), "Epoch signature verified with a different prefix")
+		assert.False(t, VerifySingleSignature(curve, sig, vk, msg), "Epoch signature verified as a plain signature")
+	}
+}
+
+func TestSignWithNonce(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := []byte("session message")
+		nonce := []byte("server nonce 1")
+		sig := SignWithNonce(curve, sk, msg, nonce)
+		assert.True(t, VerifyWithNonce(curve, sig, vk, msg, nonce), "Nonce bound signature failed verification")
+		assert.False(t, VerifyWithNonce(curve, sig, vk, msg, []byte("server nonce 2")),
+			"Nonce bound signature verified with the wrong nonce")
+		assert.False(t, VerifyWithNonce(curve, sig, vk, msg, nil), "Nonce bound signature verified without a nonce")
+		assert.False(t, VerifyWithNonce(curve, sig, vk, []byte("other message"), nonce),
+			"Nonce bound signature verified on the wrong message")
+		assert.False(t, VerifySingleSignature(curve, sig, vk, msg), "Nonce bound signature verified as a plain signature")
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements reading and writing keystores in the format of EIP-2335
+// (https://eips.ethereum.org/EIPS/eip-2335). A keystore holds a secret key
+// encrypted with AES-128-CTR, under a key derived from a password with scrypt
+// or pbkdf2. A sha256 checksum over part of the derived key and the ciphertext
+// detects a wrong password.
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrKeystoreChecksum is returned when a keystore's checksum doesn't match,
+// which is usually because the password is wrong.
+var ErrKeystoreChecksum = errors.New("keystore checksum mismatch")
+
+// ErrUnsupportedKeystore is returned for keystores with an unknown version,
+// kdf, checksum or cipher function.
+var ErrUnsupportedKeystore = errors.New("unsupported keystore")
+
+// KDFType selects the key derivation function a keystore is encrypted with.
+type KDFType int
+
+const (
+	// ScryptKDF derives the keystore's key with scrypt.
+	ScryptKDF KDFType = iota
+	// PBKDF2KDF derives the keystore's key with pbkdf2 over hmac-sha256.
+	PBKDF2KDF
+)
+
+const keystoreVersion = 4
+
+// The kdf work factors recommended by EIP-2335.
+const (
+	keystoreScryptN     = 1 << 18
+	keystoreScryptR     = 8
+	keystoreScryptP     = 1
+	keystorePBKDF2Count = 1 << 18
+)
+
+type keystoreModule struct {
+	Function string          `json:"function"`
+	Params   json.RawMessage `json:"params"`
+	Message  string          `json:"message"`
+}
+
+type keystoreJSON struct {
+	Crypto struct {
+		Kdf      keystoreModule `json:"kdf"`
+		Checksum keystoreModule `json:"checksum"`
+		Cipher   keystoreModule `json:"cipher"`
+	} `json:"crypto"`
+	Description string `json:"description"`
+	Pubkey      string `json:"pubkey"`
+	Path        string `json:"path"`
+	UUID        string `json:"uuid"`
+	Version     int    `json:"version"`
+}
+
+type scryptParams struct {
+	Dklen int    `json:"dklen"`
+	N     int    `json:"n"`
+	P     int    `json:"p"`
+	R     int    `json:"r"`
+	Salt  string `json:"salt"`
+}
+
+type pbkdf2Params struct {
+	Dklen int    `json:"dklen"`
+	C     int    `json:"c"`
+	Prf   string `json:"prf"`
+	Salt  string `json:"salt"`
+}
+
+type aesParams struct {
+	IV string `json:"iv"`
+}
+
+// DecryptKeystore decrypts an EIP-2335 keystore with the given password, and
+// returns the secret key. ErrKeystoreChecksum is returned if the password is wrong.
+//
+// The password has its control codes removed as EIP-2335 specifies, but it isn't
+// NFKD normalized, so non ascii passwords must be given in normalized form.
+// The keystore's pubkey field isn't checked, since it depends on the curve the key
+// is for. The caller can compare it against LoadPublicKey of the returned key.
+func DecryptKeystore(keystore []byte, password string) (*big.Int, error) {
+	var ks keystoreJSON
+	if err := json.Unmarshal(keystore, &ks); err != nil {
+		return nil, err
+	}
+	if ks.Version != keystoreVersion || ks.Crypto.Checksum.Function != "sha256" ||
+		ks.Crypto.Cipher.Function != "aes-128-ctr" {
+		return nil, ErrUnsupportedKeystore
+	}
+	key, err := keystoreDecryptionKey(ks.Crypto.Kdf, keystorePassword(password))
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := hex.DecodeString(ks.Crypto.Cipher.Message)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := hex.DecodeString(ks.Crypto.Checksum.Message)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(keystoreChecksum(key, ciphertext), checksum) {
+		return nil, ErrKeystoreChecksum
+	}
+	var params aesParams
+	if err := json.Unmarshal(ks.Crypto.Cipher.Params, &params); err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(params.IV)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := keystoreAES(key, iv, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(secret), nil
+}
+
+// EncryptKeystore encrypts the secret key into an EIP-2335 keystore with the given
+// password, deriving the encryption key with the selected kdf. As with
+// DecryptKeystore, the password isn't NFKD normalized, and the keystore's pubkey
+// and path fields are left empty.
+func EncryptKeystore(sk *big.Int, password string, kdf KDFType) ([]byte, error) {
+	secret, ok := secretKeyBytes(sk)
+	if !ok {
+		return nil, ErrInvalidSecretKey
+	}
+	random := make([]byte, 32+aes.BlockSize+16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	salt, iv, id := random[:32], random[32:32+aes.BlockSize], random[32+aes.BlockSize:]
+
+	var ks keystoreJSON
+	ks.Version = keystoreVersion
+	ks.UUID = keystoreUUID(id)
+	switch kdf {
+	case ScryptKDF:
+		ks.Crypto.Kdf.Function = "scrypt"
+		ks.Crypto.Kdf.Params, _ = json.Marshal(scryptParams{Dklen: 32, N: keystoreScryptN,
+			P: keystoreScryptP, R: keystoreScryptR, Salt: hex.EncodeToString(salt)})
+	case PBKDF2KDF:
+		ks.Crypto.Kdf.Function = "pbkdf2"
+		ks.Crypto.Kdf.Params, _ = json.Marshal(pbkdf2Params{Dklen: 32, C: keystorePBKDF2Count,
+			Prf: "hmac-sha256", Salt: hex.EncodeToString(salt)})
+	default:
+		return nil, ErrUnsupportedKeystore
+	}
+	key, err := keystoreDecryptionKey(ks.Crypto.Kdf, keystorePassword(password))
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := keystoreAES(key, iv, secret)
+	if err != nil {
+		return nil, err
+	}
+	ks.Crypto.Cipher.Function = "aes-128-ctr"
+	ks.Crypto.Cipher.Params, _ = json.Marshal(aesParams{IV: hex.EncodeToString(iv)})
+	ks.Crypto.Cipher.Message = hex.EncodeToString(ciphertext)
+	ks.Crypto.Checksum.Function = "sha256"
+	ks.Crypto.Checksum.Params = json.RawMessage("{}")
+	ks.Crypto.Checksum.Message = hex.EncodeToString(keystoreChecksum(key, ciphertext))
+	return json.Marshal(ks)
+}
+
+// keystoreUUID formats 16 random bytes as a version 4 uuid.
+func keystoreUUID(b []byte) string {
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// keystoreDecryptionKey derives the decryption key from the password, with the
+// kdf described by the keystore's kdf module.
+func keystoreDecryptionKey(kdf keystoreModule, password []byte) ([]byte, error) {
+	switch kdf.Function {
+	case "scrypt":
+		var params scryptParams
+		if err := json.Unmarshal(kdf.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.Dklen < 32 {
+			return nil, ErrUnsupportedKeystore
+		}
+		salt, err := hex.DecodeString(params.Salt)
+		if err != nil {
+			return nil, err
+		}
+		return scrypt.Key(password, salt, params.N, params.R, params.P, params.Dklen)
+	case "pbkdf2":
+		var params pbkdf2Params
+		if err := json.Unmarshal(kdf.Params, &params); err != nil {
+			return nil, err
+		}
+		if params.Dklen < 32 || params.C < 1 || params.Prf != "hmac-sha256" {
+			return nil, ErrUnsupportedKeystore
+		}
+		salt, err := hex.DecodeString(params.Salt)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(password, salt, params.C, params.Dklen, sha256.New), nil
+	}
+	return nil, ErrUnsupportedKeystore
+}
+
+// keystoreChecksum is sha256 of the second 16 bytes of the decryption key,
+// followed by the ciphertext.
+func keystoreChecksum(key []byte, ciphertext []byte) []byte {
+	h := sha256.New()
+	h.Write(key[16:32])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+// keystoreAES encrypts or decrypts with AES-128-CTR, keyed by the first 16 bytes
+// of the decryption key.
+func keystoreAES(key []byte, iv []byte, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key[:16])
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, ErrUnsupportedKeystore
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// keystorePassword removes the C0, C1 and delete control codes from the password.
+func keystorePassword(password string) []byte {
+	out := make([]byte, 0, len(password))
+	for _, r := range password {
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			continue
+		}
+		out = append(out, string(r)...)
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements a Schnorr proof of knowledge of the secret key behind a
+// public key in G2, made non interactive with the Fiat-Shamir transform. It is
+// a cheaper alternative to Authenticate for registering keys, as it needs no
+// hashing to the curve and no pairings to check.
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// knowledgeChallengeTag and knowledgeNonceTag prefix the hash inputs for a proof's
+// challenge and nonce respectively.
+var (
+	knowledgeChallengeTag = []byte("BGLS_KOSK_CHALLENGE")
+	knowledgeNonceTag     = []byte("BGLS_KOSK_NONCE")
+)
+
+// KoSKProof is a proof of knowledge of the secret key sk behind a public key.
+// Commitment is k*g_2 for a nonce k, and Response is k + c*sk mod the group order,
+// where the challenge c is a hash of the public key and the commitment.
+type KoSKProof struct {
+	Commitment Point
+	Response   *big.Int
+}
+
+// ProveKnowledge proves knowledge of sk for the public key LoadPublicKey(curve, sk).
+// The nonce is derived deterministically from sk, so the same key always produces
+// the same proof and no randomness is needed.
+func ProveKnowledge(curve CurveSystem, sk *big.Int) KoSKProof {
+	requireCurve(curve, "ProveKnowledge")
+	order := curve.GetG1Order()
+	x := new(big.Int).Mod(sk, order)
+	pubKey := LoadPublicKey(curve, x)
+	skBytes, _ := secretKeyBytes(x)
+
+	h := sha512.New()
+	h.Write(knowledgeNonceTag)
+	h.Write(skBytes)
+	h.Write(pubKey.Marshal())
+	k := new(big.Int).SetBytes(h.Sum(nil))
+	k.Mod(k, new(big.Int).Sub(order, big.NewInt(1))).Add(k, big.NewInt(1))
+
+	commitment := curve.GetG2().Mul(k)
+	c := knowledgeChallenge(curve, pubKey, commitment)
+	response := new(big.Int).Mul(c, x)
+	response.Add(response, k).Mod(response, order)
+	return KoSKProof{Commitment: commitment, Response: response}
+}
+
+// VerifyKnowledge checks that proof proves knowledge of the secret key behind
+// pubKey, by checking Response*g_2 = Commitment + c*pubKey. The challenge commits
+// to pubKey, so a proof for one key doesn't verify for any other.
+func VerifyKnowledge(curve CurveSystem, pubKey Point, proof KoSKProof) bool {
+	requireCurve(curve, "VerifyKnowledge")
+	if pubKey == nil || proof.Commitment == nil || proof.Response == nil {
+		return false
+	}
+	if proof.Response.Sign() < 0 || proof.Response.Cmp(curve.GetG1Order()) >= 0 {
+		return false
+	}
+	if !pubKey.InCorrectSubgroup() || !proof.Commitment.InCorrectSubgroup() {
+		return false
+	}
+	c := knowledgeChallenge(curve, pubKey, proof.Commitment)
+	expected, ok := pubKey.Mul(c).Add(proof.Commitment)
+	if !ok {
+		return false
+	}
+	return curve.GetG2().Mul(proof.Response).Equals(expected)
+}
+
+// knowledgeChallenge is the Fiat-Shamir challenge for a proof, which is sha256
+// of a tag, the public key and the commitment, reduced mod the group order.
+func knowledgeChallenge(curve CurveSystem, pubKey Point, commitment Point) *big.Int {
+	h := sha256.New()
+	h.Write(knowledgeChallengeTag)
+	h.Write(pubKey.Marshal())
+	h.Write(commitment.Marshal())
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, curve.GetG1Order())
+}
@@ -0,0 +1,34 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProveKnowledge(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		proof := ProveKnowledge(curve, sk)
+		assert.True(t, VerifyKnowledge(curve, vk, proof), "Proof of knowledge failed to verify")
+		again := ProveKnowledge(curve, sk)
+		assert.True(t, again.Commitment.Equals(proof.Commitment) && again.Response.Cmp(proof.Response) == 0,
+			"Proof of knowledge isn't deterministic")
+
+		_, vk2, _ := KeyGen(curve)
+		assert.False(t, VerifyKnowledge(curve, vk2, proof), "Proof of knowledge verified for a different key")
+		proof2 := ProveKnowledge(curve, new(big.Int).Add(sk, big.NewInt(1)))
+		assert.False(t, VerifyKnowledge(curve, vk, proof2), "Proof for a different key verified")
+
+		tampered := KoSKProof{Commitment: proof.Commitment, Response: new(big.Int).Add(proof.Response, big.NewInt(1))}
+		assert.False(t, VerifyKnowledge(curve, vk, tampered), "Tampered response verified")
+		tampered = KoSKProof{Commitment: proof.Commitment.Copy().Mul(big.NewInt(2)), Response: proof.Response}
+		assert.False(t, VerifyKnowledge(curve, vk, tampered), "Tampered commitment verified")
+		assert.False(t, VerifyKnowledge(curve, vk, KoSKProof{}), "Empty proof verified")
+		assert.False(t, VerifyKnowledge(curve, curve.GetG2(), proof), "Proof verified for the generator")
+	}
+}
@@ -0,0 +1,437 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements the wire format for aggregate and multi signatures.
+// Every serialization starts with a one byte version tag, which is currently 0.
+// All points are written in their compressed form, and all lengths are written
+// as 4 byte big endian integers.
+//
+// A version 0 AggSig is serialized as:
+// 0 || sig || n || key_1 || len(msg_1) || msg_1 || ... || key_n || len(msg_n) || msg_n
+//
+// A version 0 MultiSig is serialized as:
+// 0 || sig || n || key_1 || ... || key_n || len(msg) || msg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"sort"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// lengthPrefixSize is the number of bytes used to encode counts and message lengths.
+const lengthPrefixSize = 4
+
+// wireVersion is the version tag written by Marshal.
+const wireVersion = 0
+
+// ErrUnsupportedVersion is returned when unmarshalling data with an unknown version tag.
+var ErrUnsupportedVersion = errors.New("unsupported serialization version")
+
+// ErrInvalidEncoding is returned when unmarshalling malformed data.
+var ErrInvalidEncoding = errors.New("invalid serialization")
+
+// NewAggSig creates an aggregate signature from paired keys and messages and
+// their aggregated signature. It returns false if there is not exactly one
+// message per key, or if the signature is nil.
+func NewAggSig(keys []Point, msgs [][]byte, sig Point) (*AggSig, bool) {
+	if len(keys) != len(msgs) || sig == nil {
+		return nil, false
+	}
+	return &AggSig{keys: keys, msgs: msgs, sig: sig}, true
+}
+
+// NewMultiSig creates a multi signature from the set of keys that signed msg,
+// and their aggregated signature. It returns false if the signature is nil.
+func NewMultiSig(keys []Point, sig Point, msg []byte) (*MultiSig, bool) {
+	if sig == nil {
+		return nil, false
+	}
+	return &MultiSig{keys: keys, sig: sig, msg: msg}, true
+}
+
+// MarshalSecretKey encodes the secret key as exactly 32 big endian bytes, padded
+// with leading zeros. It returns nil if the key isn't in [1, order-1].
+func MarshalSecretKey(curve CurveSystem, sk *big.Int) []byte {
+	if !validSecretKey(curve, sk) {
+		return nil
+	}
+	encoded, _ := secretKeyBytes(sk)
+	return encoded
+}
+
+// UnmarshalSecretKey decodes a secret key encoded by MarshalSecretKey. It returns
+// ErrInvalidEncoding if the encoding isn't 32 bytes long, and ErrInvalidSecretKey
+// if the key isn't in [1, order-1].
+func UnmarshalSecretKey(curve CurveSystem, data []byte) (*big.Int, error) {
+	if len(data) != secretKeySize {
+		return nil, ErrInvalidEncoding
+	}
+	sk := new(big.Int).SetBytes(data)
+	if !validSecretKey(curve, sk) {
+		return nil, ErrInvalidSecretKey
+	}
+	return sk, nil
+}
+
+// validSecretKey checks that sk is in [1, order-1].
+func validSecretKey(curve CurveSystem, sk *big.Int) bool {
+	return sk != nil && sk.Sign() > 0 && sk.Cmp(curve.GetG1Order()) < 0
+}
+
+// Marshal serializes the aggregate signature, using compressed points.
+func (a *AggSig) Marshal() []byte {
+	out := make([]byte, 0, a.MarshalSize())
+	out = append(out, wireVersion)
+	out = append(out, a.sig.Marshal()...)
+	out = appendLength(out, len(a.keys))
+	for i := 0; i < len(a.keys); i++ {
+		out = append(out, a.keys[i].Marshal()...)
+		out = appendLength(out, len(a.msgs[i]))
+		out = append(out, a.msgs[i]...)
+	}
+	return out
+}
+
+// WriteTo writes the serialization of the aggregate signature to w, as Marshal
+// returns it, without building it in memory. Each key and message length is
+// written together, and each message is written directly from the aggregate
+// signature. It implements io.WriterTo.
+func (a *AggSig) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	write := func(b []byte) error {
+		n, err := w.Write(b)
+		written += int64(n)
+		return err
+	}
+	header := append([]byte{wireVersion}, a.sig.Marshal()...)
+	if err := write(appendLength(header, len(a.keys))); err != nil {
+		return written, err
+	}
+	for i := 0; i < len(a.keys); i++ {
+		if err := write(appendLength(a.keys[i].Marshal(), len(a.msgs[i]))); err != nil {
+			return written, err
+		}
+		if err := write(a.msgs[i]); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// MarshalSize returns the number of bytes that Marshal will output. It's
+// computed from the curve's point sizes, without marshalling any points.
+func (a *AggSig) MarshalSize() int {
+	params := a.sig.Curve().Parameters()
+	size := 1 + params.G1Size + lengthPrefixSize
+	size += len(a.keys) * (params.G2Size + lengthPrefixSize)
+	for i := 0; i < len(a.msgs); i++ {
+		size += len(a.msgs[i])
+	}
+	return size
+}
+
+// CanonicalMessageOrder returns the permutation which sorts msgs
+// lexicographically, i.e. msgs[order[0]], msgs[order[1]], ... is sorted. Equal
+// messages keep their relative order.
+func CanonicalMessageOrder(msgs [][]byte) []int {
+	order := make([]int, len(msgs))
+	for i := 0; i < len(order); i++ {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return bytes.Compare(msgs[order[i]], msgs[order[j]]) < 0
+	})
+	return order
+}
+
+// MarshalCanonical serializes the aggregate signature as Marshal does, but with
+// the key and message pairs in canonical order, so that every ordering of the
+// same pairs serializes identically. Pairs are sorted by message, and then by
+// compressed key.
+func (a *AggSig) MarshalCanonical() []byte {
+	order := make([]int, len(a.msgs))
+	for i := 0; i < len(order); i++ {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if c := bytes.Compare(a.msgs[order[i]], a.msgs[order[j]]); c != 0 {
+			return c < 0
+		}
+		return a.keys[order[i]].Cmp(a.keys[order[j]]) < 0
+	})
+	keys := make([]Point, len(order))
+	msgs := make([][]byte, len(order))
+	for i, j := range order {
+		keys[i], msgs[i] = a.keys[j], a.msgs[j]
+	}
+	return (&AggSig{keys: keys, msgs: msgs, sig: a.sig}).Marshal()
+}
+
+// Equal checks that the two aggregate signatures have the same signature, and
+// the same key and message pairs, in any order. This doesn't verify either of
+// them.
+func (a *AggSig) Equal(b *AggSig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if len(a.keys) != len(b.keys) || !a.sig.Equals(b.sig) {
+		return false
+	}
+	return bytes.Equal(a.MarshalCanonical(), b.MarshalCanonical())
+}
+
+// UnmarshalAggSig deserializes an aggregate signature produced by Marshal. It returns
+// ErrUnsupportedVersion if the data has an unknown version tag, and
+// ErrInvalidEncoding if the data is otherwise malformed.
+func UnmarshalAggSig(curve CurveSystem, data []byte) (*AggSig, error) {
+	if len(data) == 0 {
+		return nil, ErrInvalidEncoding
+	}
+	switch data[0] {
+	case wireVersion:
+		if decoded, ok := unmarshalAggSigV0(curve, data[1:]); ok {
+			return decoded, nil
+		}
+		return nil, ErrInvalidEncoding
+	default:
+		return nil, ErrUnsupportedVersion
+	}
+}
+
+// unmarshalAggSigV0 deserializes the body of a version 0 aggregate signature.
+func unmarshalAggSigV0(curve CurveSystem, data []byte) (*AggSig, bool) {
+	sig, rest, ok := readPoint(curve.UnmarshalG1, curve.GetG1(), data)
+	if !ok {
+		return nil, false
+	}
+	n, rest, ok := readLength(rest)
+	if !ok {
+		return nil, false
+	}
+	keySize := len(curve.GetG2().Marshal())
+	if n > len(rest)/(keySize+lengthPrefixSize) {
+		return nil, false
+	}
+	keys := make([]Point, n)
+	msgs := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		if keys[i], rest, ok = readPoint(curve.UnmarshalG2, curve.GetG2(), rest); !ok {
+			return nil, false
+		}
+		if msgs[i], rest, ok = readMessage(rest); !ok {
+			return nil, false
+		}
+	}
+	if len(rest) != 0 {
+		return nil, false
+	}
+	return &AggSig{keys: keys, msgs: msgs, sig: sig}, true
+}
+
+// Marshal serializes the multi signature, using compressed points.
+func (m MultiSig) Marshal() []byte {
+	out := make([]byte, 0, m.MarshalSize())
+	out = append(out, wireVersion)
+	out = append(out, m.sig.Marshal()...)
+	out = appendLength(out, len(m.keys))
+	for i := 0; i < len(m.keys); i++ {
+		out = append(out, m.keys[i].Marshal()...)
+	}
+	out = appendLength(out, len(m.msg))
+	return append(out, m.msg...)
+}
+
+// MarshalSize returns the number of bytes that Marshal will output. It's
+// computed from the curve's point sizes, without marshalling any points.
+func (m MultiSig) MarshalSize() int {
+	params := m.sig.Curve().Parameters()
+	return 1 + params.G1Size + 2*lengthPrefixSize + len(m.msg) + len(m.keys)*params.G2Size
+}
+
+// UnmarshalMultiSig deserializes a multi signature produced by Marshal. It returns
+// ErrUnsupportedVersion if the data has an unknown version tag, and
+// ErrInvalidEncoding if the data is otherwise malformed.
+func UnmarshalMultiSig(curve CurveSystem, data []byte) (*MultiSig, error) {
+	if len(data) == 0 {
+		return nil, ErrInvalidEncoding
+	}
+	switch data[0] {
+	case wireVersion:
+		if decoded, ok := unmarshalMultiSigV0(curve, data[1:]); ok {
+			return decoded, nil
+		}
+		return nil, ErrInvalidEncoding
+	default:
+		return nil, ErrUnsupportedVersion
+	}
+}
+
+// unmarshalMultiSigV0 deserializes the body of a version 0 multi signature.
+func unmarshalMultiSigV0(curve CurveSystem, data []byte) (*MultiSig, bool) {
+	sig, rest, ok := readPoint(curve.UnmarshalG1, curve.GetG1(), data)
+	if !ok {
+		return nil, false
+	}
+	n, rest, ok := readLength(rest)
+	if !ok {
+		return nil, false
+	}
+	keySize := len(curve.GetG2().Marshal())
+	if n > len(rest)/keySize {
+		return nil, false
+	}
+	keys := make([]Point, n)
+	for i := 0; i < n; i++ {
+		if keys[i], rest, ok = readPoint(curve.UnmarshalG2, curve.GetG2(), rest); !ok {
+			return nil, false
+		}
+	}
+	msg, rest, ok := readMessage(rest)
+	if !ok || len(rest) != 0 {
+		return nil, false
+	}
+	return &MultiSig{keys: keys, sig: sig, msg: msg}, true
+}
+
+// VerifyAggregatePacked parses packed as a compressed aggregate signature
+// followed by one or more compressed public keys, with no length prefixes, and
+// checks that it is a multi signature of standard signatures on msg by all of
+// the keys. As with all multi signatures, the keys must have been protected
+// against the rogue public key attack. See doc.go for more details. It returns
+// ErrInvalidEncoding if packed can't be parsed, and otherwise a nil error and
+// the result of verification.
+func VerifyAggregatePacked(curve CurveSystem, packed []byte, msg []byte) (bool, error) {
+	sig, rest, ok := readPoint(curve.UnmarshalG1, curve.GetG1(), packed)
+	if !ok {
+		return false, ErrInvalidEncoding
+	}
+	keySize := len(curve.GetG2().Marshal())
+	if len(rest) == 0 || len(rest)%keySize != 0 {
+		return false, ErrInvalidEncoding
+	}
+	keys := make([]Point, len(rest)/keySize)
+	for i := 0; i < len(keys); i++ {
+		if keys[i], rest, ok = readPoint(curve.UnmarshalG2, curve.GetG2(), rest); !ok {
+			return false, ErrInvalidEncoding
+		}
+	}
+	return verifyMultiSignature(curve, sig, keys, msg), nil
+}
+
+// VerifyAggregateSignatureCompressedKeys decodes compressed public keys and
+// checks the aggregate signature against them, as VerifyAggregateSignature
+// does. A key which can't be decoded gives ErrInvalidEncoding, and one outside
+// of its subgroup ErrNotInSubgroup, rather than a failed verification. It returns
+// ErrLengthMismatch if the keys and messages differ in number.
+func VerifyAggregateSignatureCompressedKeys(curve CurveSystem, aggsig Point, keyBytes [][]byte,
+	msgs [][]byte) (bool, error) {
+	if len(keyBytes) != len(msgs) {
+		return false, ErrLengthMismatch
+	}
+	size := len(curve.GetG2().Marshal())
+	keys := make([]Point, len(keyBytes))
+	for i := 0; i < len(keyBytes); i++ {
+		if len(keyBytes[i]) != size {
+			return false, ErrInvalidEncoding
+		}
+		key, ok := curve.UnmarshalG2(append([]byte{}, keyBytes[i]...))
+		if !ok {
+			return false, ErrInvalidEncoding
+		}
+		if !key.InCorrectSubgroup() {
+			return false, ErrNotInSubgroup
+		}
+		keys[i] = key
+	}
+	return VerifyAggregateSignature(curve, aggsig, keys, msgs), nil
+}
+
+func appendLength(out []byte, n int) []byte {
+	var buf [lengthPrefixSize]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(n))
+	return append(out, buf[:]...)
+}
+
+func readLength(data []byte) (int, []byte, bool) {
+	if len(data) < lengthPrefixSize {
+		return 0, nil, false
+	}
+	return int(binary.BigEndian.Uint32(data)), data[lengthPrefixSize:], true
+}
+
+// readMessage reads a length prefixed message, and returns a copy of it.
+func readMessage(data []byte) ([]byte, []byte, bool) {
+	n, rest, ok := readLength(data)
+	if !ok || n > len(rest) {
+		return nil, nil, false
+	}
+	return append([]byte{}, rest[:n]...), rest[n:], true
+}
+
+// readPoint reads a compressed point of the same group as generator. The bytes
+// are copied before unmarshalling, as unmarshalling may modify its input.
+func readPoint(unmarshal func([]byte) (Point, bool), generator Point, data []byte) (Point, []byte, bool) {
+	size := len(generator.Marshal())
+	if len(data) < size {
+		return nil, nil, false
+	}
+	pt, ok := unmarshal(append([]byte{}, data[:size]...))
+	return pt, data[size:], ok
+}
+
+// AggregateCompressed decodes compressed signatures and sums them, running one
+// subgroup check on the sum rather than one per signature. Decoding still checks
+// that each signature is on the curve. It returns ErrInvalidEncoding if there
+// are no signatures or any can't be decoded, and ErrNotInSubgroup if the sum
+// isn't in the subgroup.
+//
+// The sum being in the subgroup doesn't prove that each signature was, as the
+// components outside of the subgroup of two signatures can cancel. This is only
+// safe when the sum is then verified, and a valid aggregate is all that matters,
+// rather than the validity of each signature. Use AggregateCompressedStrict to
+// check every signature.
+func AggregateCompressed(curve CurveSystem, sigBytes [][]byte) (Point, error) {
+	return aggregateCompressed(curve, sigBytes, false)
+}
+
+// AggregateCompressedStrict decodes and sums compressed signatures as
+// AggregateCompressed does, but checks that every signature is in the subgroup.
+func AggregateCompressedStrict(curve CurveSystem, sigBytes [][]byte) (Point, error) {
+	return aggregateCompressed(curve, sigBytes, true)
+}
+
+func aggregateCompressed(curve CurveSystem, sigBytes [][]byte, strict bool) (Point, error) {
+	if len(sigBytes) == 0 {
+		return nil, ErrInvalidEncoding
+	}
+	size := len(curve.GetG1().Marshal())
+	sigs := make([]Point, len(sigBytes))
+	for i := 0; i < len(sigBytes); i++ {
+		if len(sigBytes[i]) != size {
+			return nil, ErrInvalidEncoding
+		}
+		sig, ok := curve.UnmarshalG1(append([]byte{}, sigBytes[i]...))
+		if !ok {
+			return nil, ErrInvalidEncoding
+		}
+		if strict && !sig.InCorrectSubgroup() {
+			return nil, ErrNotInSubgroup
+		}
+		sigs[i] = sig
+	}
+	aggsig, err := SafeAggregatePoints(sigs)
+	if err != nil {
+		return nil, err
+	}
+	if !aggsig.InCorrectSubgroup() {
+		return nil, ErrNotInSubgroup
+	}
+	return aggsig, nil
+}
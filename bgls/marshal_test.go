@@ -0,0 +1,336 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"math/big"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggSigMarshal(t *testing.T) {
+	for _, curve := range curves {
+		N := 5
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			// Use differing message lengths, including an empty message.
+			msgs[i] = make([]byte, 8*i)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			keys[i] = vk
+		}
+		a, ok := NewAggSig(keys, msgs, AggregateSignatures(sigs))
+		assert.True(t, ok, "Creating an AggSig failed")
+		data := a.Marshal()
+		assert.Equal(t, len(data), a.MarshalSize(), "MarshalSize doesn't match Marshal")
+		assert.Equal(t, byte(0), data[0], "AggSig wasn't serialized as version 0")
+		b, err := UnmarshalAggSig(curve, data)
+		assert.Nil(t, err, "Unmarshalling AggSig failed")
+		assert.True(t, b.Verify(curve), "Unmarshalled AggSig failed verification")
+		assert.Equal(t, data, b.Marshal(), "Unmarshal is not consistent with Marshal")
+
+		empty, ok := NewAggSig([]Point{}, [][]byte{}, curve.GetG1Infinity())
+		assert.True(t, ok, "Creating an empty AggSig failed")
+		assert.Equal(t, len(empty.Marshal()), empty.MarshalSize())
+
+		_, ok = NewAggSig(keys, msgs[1:], AggregateSignatures(sigs))
+		assert.False(t, ok, "Created an AggSig with fewer messages than keys")
+		_, ok = NewAggSig(keys, msgs, nil)
+		assert.False(t, ok, "Created an AggSig without a signature")
+
+		_, err = UnmarshalAggSig(curve, data[:len(data)-1])
+		assert.Equal(t, ErrInvalidEncoding, err, "Unmarshalling a truncated AggSig succeeded")
+		_, err = UnmarshalAggSig(curve, append(data, 0))
+		assert.Equal(t, ErrInvalidEncoding, err, "Unmarshalling an AggSig with trailing data succeeded")
+		_, err = UnmarshalAggSig(curve, []byte{})
+		assert.Equal(t, ErrInvalidEncoding, err, "Unmarshalling an empty AggSig succeeded")
+		future := append([]byte{}, data...)
+		future[0] = 1
+		_, err = UnmarshalAggSig(curve, future)
+		assert.Equal(t, ErrUnsupportedVersion, err, "Unmarshalling an AggSig with a future version succeeded")
+	}
+}
+
+// limitedWriter fails once more than limit bytes have been written.
+type limitedWriter struct {
+	limit int
+}
+
+func (w *limitedWriter) Write(b []byte) (int, error) {
+	if len(b) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, io.ErrShortWrite
+	}
+	w.limit -= len(b)
+	return len(b), nil
+}
+
+func TestAggSigWriteTo(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, 8*i)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			keys[i] = vk
+		}
+		a, _ := NewAggSig(keys, msgs, AggregateSignatures(sigs))
+		var buf bytes.Buffer
+		n, err := a.WriteTo(&buf)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(a.MarshalSize()), n)
+		assert.Equal(t, a.Marshal(), buf.Bytes(), "WriteTo differs from Marshal")
+		b, err := UnmarshalAggSig(curve, buf.Bytes())
+		assert.Nil(t, err)
+		assert.True(t, a.Equal(b), "AggSig read back differs from the one written")
+
+		n, err = a.WriteTo(&limitedWriter{limit: 50})
+		assert.Equal(t, io.ErrShortWrite, err)
+		assert.Equal(t, int64(50), n)
+	}
+}
+
+func TestMultiSigMarshal(t *testing.T) {
+	for _, curve := range curves {
+		N := 5
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		sigs := make([]Point, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = KoskSign(curve, sk, msg)
+			keys[i] = vk
+		}
+		m, ok := NewMultiSig(keys, AggregateSignatures(sigs), msg)
+		assert.True(t, ok, "Creating a MultiSig failed")
+		data := m.Marshal()
+		assert.Equal(t, len(data), m.MarshalSize(), "MarshalSize doesn't match Marshal")
+		assert.Equal(t, byte(0), data[0], "MultiSig wasn't serialized as version 0")
+		m2, err := UnmarshalMultiSig(curve, data)
+		assert.Nil(t, err, "Unmarshalling MultiSig failed")
+		assert.True(t, m2.Verify(curve), "Unmarshalled MultiSig failed verification")
+		assert.Equal(t, data, m2.Marshal(), "Unmarshal is not consistent with Marshal")
+
+		_, err = UnmarshalMultiSig(curve, data[:len(data)-1])
+		assert.Equal(t, ErrInvalidEncoding, err, "Unmarshalling a truncated MultiSig succeeded")
+		future := append([]byte{}, data...)
+		future[0] = 255
+		_, err = UnmarshalMultiSig(curve, future)
+		assert.Equal(t, ErrUnsupportedVersion, err, "Unmarshalling a MultiSig with a future version succeeded")
+		_, ok = NewMultiSig(keys, nil, msg)
+		assert.False(t, ok, "Created a MultiSig without a signature")
+	}
+}
+
+func TestCanonicalAggSigMarshal(t *testing.T) {
+	for _, curve := range curves {
+		N := 6
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, 1+i%3)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			keys[i] = vk
+		}
+		order := CanonicalMessageOrder(msgs)
+		for i := 1; i < N; i++ {
+			assert.True(t, bytes.Compare(msgs[order[i-1]], msgs[order[i]]) <= 0, "Messages aren't in canonical order")
+		}
+
+		perm := []int{3, 0, 5, 1, 4, 2}
+		shuffledKeys := make([]Point, N)
+		shuffledMsgs := make([][]byte, N)
+		for i, j := range perm {
+			shuffledKeys[i], shuffledMsgs[i] = keys[j], msgs[j]
+		}
+		a, _ := NewAggSig(keys, msgs, AggregateSignatures(sigs))
+		b, _ := NewAggSig(shuffledKeys, shuffledMsgs, AggregateSignatures(sigs))
+		assert.NotEqual(t, a.Marshal(), b.Marshal())
+		assert.Equal(t, a.MarshalCanonical(), b.MarshalCanonical(), "Canonical serializations differ")
+		assert.True(t, a.Equal(b), "Reordered AggSigs aren't equal")
+		assert.True(t, b.Equal(a), "Reordered AggSigs aren't equal")
+		otherSig, _ := AggregateSignatures(sigs).Add(curve.GetG1())
+		d, _ := NewAggSig(shuffledKeys, shuffledMsgs, otherSig)
+		assert.False(t, a.Equal(d), "AggSigs with different signatures are equal")
+		e, _ := NewAggSig(keys[1:], msgs[1:], AggregateSignatures(sigs))
+		assert.False(t, a.Equal(e), "AggSigs with different keys are equal")
+		assert.False(t, a.Equal(nil))
+		c, err := UnmarshalAggSig(curve, b.MarshalCanonical())
+		assert.Nil(t, err)
+		assert.True(t, c.Verify(curve), "Canonical AggSig failed verification")
+	}
+}
+
+func TestVerifyAggregatePacked(t *testing.T) {
+	for _, curve := range curves {
+		N := 10
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		sigs := make([]Point, N)
+		keys := []byte{}
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msg)
+			keys = append(keys, vk.Marshal()...)
+		}
+		packed := append(AggregateSignatures(sigs).Marshal(), keys...)
+		ok, err := VerifyAggregatePacked(curve, packed, msg)
+		assert.Nil(t, err)
+		assert.True(t, ok, "Packed aggregate verification failed")
+
+		msg2 := make([]byte, 32)
+		rand.Read(msg2)
+		ok, err = VerifyAggregatePacked(curve, packed, msg2)
+		assert.Nil(t, err, "Verification failure was reported as a parse error")
+		assert.False(t, ok, "Packed aggregate verification succeeded on the wrong message")
+
+		_, err = VerifyAggregatePacked(curve, packed[:len(packed)-1], msg)
+		assert.Equal(t, ErrInvalidEncoding, err, "Parsed a truncated packed aggregate")
+		_, err = VerifyAggregatePacked(curve, AggregateSignatures(sigs).Marshal(), msg)
+		assert.Equal(t, ErrInvalidEncoding, err, "Parsed a packed aggregate without keys")
+		_, err = VerifyAggregatePacked(curve, []byte{}, msg)
+		assert.Equal(t, ErrInvalidEncoding, err)
+	}
+}
+
+func TestMarshalSecretKey(t *testing.T) {
+	for _, curve := range curves {
+		// A key with two leading zero bytes
+		sk := new(big.Int).Rsh(curve.GetG1Order(), 20)
+		encoded := MarshalSecretKey(curve, sk)
+		assert.Equal(t, 32, len(encoded))
+		assert.Equal(t, []byte{0, 0}, encoded[:2])
+		decoded, err := UnmarshalSecretKey(curve, encoded)
+		assert.Nil(t, err)
+		assert.True(t, SecretKeyEqual(sk, decoded), "Secret key round trip changed the key")
+
+		for i := 0; i < 10; i++ {
+			sk, _, _ = KeyGen(curve)
+			encoded = MarshalSecretKey(curve, sk)
+			assert.Equal(t, 32, len(encoded), "Secret key encoding isn't fixed width")
+			decoded, _ = UnmarshalSecretKey(curve, encoded)
+			assert.True(t, SecretKeyEqual(sk, decoded), "Secret key round trip changed the key")
+		}
+		assert.Equal(t, 32, len(MarshalSecretKey(curve, big.NewInt(1))))
+		assert.Nil(t, MarshalSecretKey(curve, big.NewInt(0)))
+		assert.Nil(t, MarshalSecretKey(curve, curve.GetG1Order()))
+
+		_, err = UnmarshalSecretKey(curve, encoded[1:])
+		assert.Equal(t, ErrInvalidEncoding, err)
+		_, err = UnmarshalSecretKey(curve, make([]byte, 32))
+		assert.Equal(t, ErrInvalidSecretKey, err)
+		order := make([]byte, 32)
+		copy(order[32-len(curve.GetG1Order().Bytes()):], curve.GetG1Order().Bytes())
+		_, err = UnmarshalSecretKey(curve, order)
+		assert.Equal(t, ErrInvalidSecretKey, err)
+	}
+}
+
+// outsideSubgroupCurve decodes G1 points as points which claim not to be in
+// their subgroup, but whose sums are ordinary points.
+type outsideSubgroupCurve struct {
+	CurveSystem
+}
+
+func (curve outsideSubgroupCurve) UnmarshalG1(data []byte) (Point, bool) {
+	pt, ok := curve.CurveSystem.UnmarshalG1(data)
+	return outsideSubgroupSummand{pt}, ok
+}
+
+type outsideSubgroupSummand struct {
+	Point
+}
+
+func (p outsideSubgroupSummand) Add(q Point) (Point, bool) {
+	if summand, ok := q.(outsideSubgroupSummand); ok {
+		q = summand.Point
+	}
+	return p.Point.Add(q)
+}
+
+func (p outsideSubgroupSummand) InCorrectSubgroup() bool {
+	return false
+}
+
+func TestAggregateCompressed(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 4, 32
+		msgs := make([][]byte, N)
+		keys := make([]Point, N)
+		sigBytes := make([][]byte, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			sigBytes[i] = Sign(curve, sk, msgs[i]).Marshal()
+		}
+		for _, aggregate := range []func(CurveSystem, [][]byte) (Point, error){AggregateCompressed, AggregateCompressedStrict} {
+			aggsig, err := aggregate(curve, sigBytes)
+			assert.Nil(t, err)
+			assert.True(t, VerifyAggregateSignature(curve, aggsig, keys, msgs), "Aggregate of compressed signatures failed to verify")
+			_, err = aggregate(curve, [][]byte{sigBytes[0], sigBytes[1][1:]})
+			assert.Equal(t, ErrInvalidEncoding, err)
+			_, err = aggregate(curve, nil)
+			assert.Equal(t, ErrInvalidEncoding, err)
+		}
+
+		// Only the strict variant notices inputs outside of the subgroup which
+		// sum to a point inside it.
+		_, err := AggregateCompressed(outsideSubgroupCurve{curve}, sigBytes)
+		assert.Nil(t, err)
+		_, err = AggregateCompressedStrict(outsideSubgroupCurve{curve}, sigBytes)
+		assert.Equal(t, ErrNotInSubgroup, err)
+	}
+}
+
+func TestVerifyAggregateSignatureCompressedKeys(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 4, 32
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		keyBytes := make([][]byte, N)
+		for i := 0; i < N; i++ {
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sk, vk, _ := KeyGen(curve)
+			sigs[i] = Sign(curve, sk, msgs[i])
+			keyBytes[i] = vk.Marshal()
+		}
+		aggsig := AggregateSignatures(sigs)
+		ok, err := VerifyAggregateSignatureCompressedKeys(curve, aggsig, keyBytes, msgs)
+		assert.Nil(t, err)
+		assert.True(t, ok, "Aggregate with compressed keys failed verification")
+		ok, err = VerifyAggregateSignatureCompressedKeys(curve, sigs[0], keyBytes, msgs)
+		assert.Nil(t, err)
+		assert.False(t, ok, "Invalid aggregate with compressed keys verified")
+
+		malformed := append([][]byte{}, keyBytes...)
+		malformed[2] = keyBytes[2][1:]
+		_, err = VerifyAggregateSignatureCompressedKeys(curve, aggsig, malformed, msgs)
+		assert.Equal(t, ErrInvalidEncoding, err, "Truncated key wasn't a decode error")
+		malformed[2] = bytes.Repeat([]byte{0xff}, len(keyBytes[2]))
+		_, err = VerifyAggregateSignatureCompressedKeys(curve, aggsig, malformed, msgs)
+		assert.Equal(t, ErrInvalidEncoding, err, "Malformed key wasn't a decode error")
+		_, err = VerifyAggregateSignatureCompressedKeys(curve, aggsig, keyBytes, msgs[1:])
+		assert.Equal(t, ErrLengthMismatch, err)
+	}
+}
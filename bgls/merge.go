@@ -0,0 +1,80 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// MergePolicy selects how MergeAggSigs handles a key and message pair which is
+// in more than one of the aggregates.
+type MergePolicy int
+
+const (
+	// MergeRejectOverlap fails the merge with ErrDuplicateSigner.
+	MergeRejectOverlap MergePolicy = iota
+	// MergeSumOverlap keeps the pair once, with its key scaled by the number of
+	// aggregates it is in, since the merged signature includes its signature
+	// that many times. This is as in KoskVerifyMultiSignatureWithMultiplicity.
+	MergeSumOverlap
+)
+
+// MergeAggSigs merges aggregate signatures, e.g. attestation aggregates from
+// several subnets, into one aggregate signature of every pair of key and message.
+// A pair in more than one of the aggregates is handled according to policy.
+// Messages must still be distinct across the merged aggregate, so the same
+// message with different keys gives ErrDuplicateMessage. It returns ErrNilPoint
+// if any aggregate is nil, and ErrDegenerateAggregate if there are none.
+func MergeAggSigs(curve CurveSystem, sigs []*AggSig, policy MergePolicy) (*AggSig, error) {
+	requireCurve(curve, "MergeAggSigs")
+	if len(sigs) == 0 {
+		return nil, ErrDegenerateAggregate
+	}
+	var keys []Point
+	var msgs [][]byte
+	var multiplicities []int64
+	aggsigs := make([]Point, len(sigs))
+	msgIndex := make(map[string]int)
+	for i, a := range sigs {
+		if a == nil || a.sig == nil {
+			return nil, ErrNilPoint
+		}
+		if err := CheckCurve(curve, a.sig); err != nil {
+			return nil, err
+		}
+		aggsigs[i] = a.sig
+		for j := 0; j < len(a.keys); j++ {
+			if a.keys[j] == nil {
+				return nil, ErrNilPoint
+			}
+			index, seen := msgIndex[string(a.msgs[j])]
+			if !seen {
+				msgIndex[string(a.msgs[j])] = len(keys)
+				keys = append(keys, a.keys[j])
+				msgs = append(msgs, a.msgs[j])
+				multiplicities = append(multiplicities, 1)
+				continue
+			}
+			if !keys[index].Equals(a.keys[j]) {
+				return nil, ErrDuplicateMessage
+			}
+			if policy != MergeSumOverlap {
+				return nil, ErrDuplicateSigner
+			}
+			multiplicities[index]++
+		}
+	}
+	for i := 0; i < len(keys); i++ {
+		if multiplicities[i] > 1 {
+			keys[i] = keys[i].Mul(big.NewInt(multiplicities[i]))
+		}
+	}
+	aggsig, err := SafeAggregatePoints(aggsigs)
+	if err != nil {
+		return nil, err
+	}
+	return &AggSig{keys: keys, msgs: msgs, sig: aggsig}, nil
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeAggSigs(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 5, 32
+		keys := make([]Point, N)
+		msgs := make([][]byte, N)
+		sigs := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		// Signer 2 is in both aggregates
+		a, _ := NewAggSig(keys[:3], msgs[:3], AggregateSignatures(sigs[:3]))
+		b, _ := NewAggSig(keys[2:], msgs[2:], AggregateSignatures(sigs[2:]))
+
+		_, err := MergeAggSigs(curve, []*AggSig{a, b}, MergeRejectOverlap)
+		assert.Equal(t, ErrDuplicateSigner, err, "Overlapping aggregates merged")
+
+		merged, err := MergeAggSigs(curve, []*AggSig{a, b}, MergeSumOverlap)
+		assert.Nil(t, err)
+		assert.Equal(t, N, len(merged.Components()), "Overlapping pair wasn't kept once")
+		assert.True(t, merged.Verify(curve), "Merged overlapping aggregate failed verification")
+
+		c, _ := NewAggSig(keys[3:], msgs[3:], AggregateSignatures(sigs[3:]))
+		merged, err = MergeAggSigs(curve, []*AggSig{a, c}, MergeRejectOverlap)
+		assert.Nil(t, err)
+		assert.True(t, merged.Verify(curve), "Merged disjoint aggregate failed verification")
+		expected, _ := NewAggSig(keys, msgs, AggregateSignatures(sigs))
+		assert.True(t, merged.Equal(expected), "Merged aggregate differs from aggregating every signature")
+
+		// The same message with a different key
+		d, _ := NewAggSig([]Point{keys[4]}, [][]byte{msgs[0]}, sigs[4])
+		_, err = MergeAggSigs(curve, []*AggSig{a, d}, MergeSumOverlap)
+		assert.Equal(t, ErrDuplicateMessage, err)
+		_, err = MergeAggSigs(curve, []*AggSig{a, nil}, MergeSumOverlap)
+		assert.Equal(t, ErrNilPoint, err)
+		_, err = MergeAggSigs(curve, nil, MergeSumOverlap)
+		assert.Equal(t, ErrDegenerateAggregate, err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements verifying aggregate signatures by keys committed to with
+// a Merkle root, e.g. a light client's view of a validator set. Leaves are
+// sha256 of a null byte and a compressed key, and inner nodes are sha256 of a
+// 0x01 byte and their two children, so a leaf can't be passed off as an inner
+// node. The leaves are padded with zero hashes up to a power of two.
+
+import (
+	"crypto/sha256"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// KeyMerkleProof proves that Key is the leaf at Index of a Merkle tree of keys.
+// Siblings are the hashes of the sibling of each node on the path from the leaf
+// to the root, starting at the leaf.
+type KeyMerkleProof struct {
+	Key      Point
+	Index    uint64
+	Siblings [][32]byte
+}
+
+// KeyMerkleTree returns the Merkle root of the keys, and a proof of inclusion for
+// each key, where proofs[i] proves keys[i]. The root of no keys is zero.
+func KeyMerkleTree(keys []Point) (root [32]byte, proofs []KeyMerkleProof) {
+	if len(keys) == 0 {
+		return root, nil
+	}
+	width := 1
+	for width < len(keys) {
+		width *= 2
+	}
+	level := make([][32]byte, width)
+	proofs = make([]KeyMerkleProof, len(keys))
+	for i := 0; i < len(keys); i++ {
+		level[i] = keyMerkleLeaf(keys[i])
+		proofs[i] = KeyMerkleProof{Key: keys[i], Index: uint64(i)}
+	}
+	for ; width > 1; width /= 2 {
+		for i := 0; i < len(keys); i++ {
+			index := proofs[i].Index >> uint(len(proofs[i].Siblings))
+			proofs[i].Siblings = append(proofs[i].Siblings, level[index^1])
+		}
+		for j := 0; j < width/2; j++ {
+			level[j] = merkleNode(level[2*j], level[2*j+1])
+		}
+	}
+	return level[0], proofs
+}
+
+// Verify checks that the proof proves its key is in the tree with the root.
+func (p KeyMerkleProof) Verify(root [32]byte) bool {
+	if p.Key == nil || len(p.Siblings) > 63 || p.Index>>uint(len(p.Siblings)) != 0 {
+		return false
+	}
+	node := keyMerkleLeaf(p.Key)
+	for i, sibling := range p.Siblings {
+		if (p.Index>>uint(i))&1 == 0 {
+			node = merkleNode(node, sibling)
+		} else {
+			node = merkleNode(sibling, node)
+		}
+	}
+	return node == root
+}
+
+// VerifyAggregateAgainstRoot checks that aggsig is an aggregate of kosk signatures
+// on msg by the keys of keysWithProofs, and that each of the keys is in the tree
+// with the root. Any failed proof of inclusion, or two proofs of the same leaf,
+// fail the check. As with FastAggregateVerify, this is only safe against the rogue
+// public key attack if each key's authentication was checked before it was
+// committed to.
+func VerifyAggregateAgainstRoot(curve CurveSystem, aggsig Point, keysWithProofs []KeyMerkleProof,
+	root [32]byte, msg []byte) bool {
+	requireCurve(curve, "VerifyAggregateAgainstRoot")
+	keys := make([]Point, len(keysWithProofs))
+	indices := make(map[uint64]bool, len(keysWithProofs))
+	for i, proof := range keysWithProofs {
+		if indices[proof.Index] || !proof.Verify(root) {
+			return false
+		}
+		indices[proof.Index] = true
+		keys[i] = proof.Key
+	}
+	return FastAggregateVerify(curve, keys, msg, aggsig)
+}
+
+func keyMerkleLeaf(key Point) [32]byte {
+	return sha256.Sum256(append([]byte{0}, key.Marshal()...))
+}
+
+func merkleNode(left, right [32]byte) [32]byte {
+	var data [65]byte
+	data[0] = 1
+	copy(data[1:33], left[:])
+	copy(data[33:], right[:])
+	return sha256.Sum256(data[:])
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyMerkleTree(t *testing.T) {
+	for _, curve := range curves {
+		for _, N := range []int{1, 2, 5, 8} {
+			keys := make([]Point, N)
+			for i := 0; i < N; i++ {
+				_, keys[i], _ = KeyGen(curve)
+			}
+			root, proofs := KeyMerkleTree(keys)
+			assert.Equal(t, N, len(proofs))
+			for i := 0; i < N; i++ {
+				assert.True(t, proofs[i].Verify(root), "Merkle proof of inclusion failed")
+				other := proofs[(i+1)%N]
+				if N > 1 {
+					assert.False(t, KeyMerkleProof{Key: keys[i], Index: other.Index, Siblings: other.Siblings}.Verify(root),
+						"Merkle proof verified for the wrong key")
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyAggregateAgainstRoot(t *testing.T) {
+	for _, curve := range curves {
+		N := 6
+		msg := []byte("light client")
+		sks := make([]*big.Int, N)
+		keys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			sks[i], keys[i], _ = KeyGen(curve)
+		}
+		root, proofs := KeyMerkleTree(keys)
+
+		signers := []int{0, 2, 3}
+		sigs := make([]Point, len(signers))
+		signerProofs := make([]KeyMerkleProof, len(signers))
+		for i, s := range signers {
+			sigs[i] = KoskSign(curve, sks[s], msg)
+			signerProofs[i] = proofs[s]
+		}
+		aggsig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateAgainstRoot(curve, aggsig, signerProofs, root, msg),
+			"Aggregate over a proven subset failed verification")
+		assert.False(t, VerifyAggregateAgainstRoot(curve, aggsig, signerProofs, root, []byte("other")),
+			"Aggregate verified on the wrong message")
+		assert.False(t, VerifyAggregateAgainstRoot(curve, aggsig, signerProofs[1:], root, msg),
+			"Aggregate verified without all of its signers")
+
+		var otherRoot [32]byte
+		assert.False(t, VerifyAggregateAgainstRoot(curve, aggsig, signerProofs, otherRoot, msg),
+			"Aggregate verified against the wrong root")
+
+		// A signer outside of the tree
+		outsideSk, outsideKey, _ := KeyGen(curve)
+		outside := KeyMerkleProof{Key: outsideKey, Index: proofs[1].Index, Siblings: proofs[1].Siblings}
+		withOutside := AggregateSignatures([]Point{aggsig, KoskSign(curve, outsideSk, msg)})
+		assert.False(t, VerifyAggregateAgainstRoot(curve, withOutside, append(signerProofs, outside), root, msg),
+			"Aggregate verified with a key outside of the tree")
+
+		// The same signer twice
+		twice := AggregateSignatures([]Point{aggsig, sigs[0]})
+		assert.False(t, VerifyAggregateAgainstRoot(curve, twice, append(signerProofs, signerProofs[0]), root, msg),
+			"Aggregate verified with a repeated proof")
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// nullifierTag is prepended to the hash input of a nullifier.
+var nullifierTag = []byte("BGLS_NULLIFIER")
+
+// Nullifier returns a deterministic identifier for a signer at an epoch, which is
+// sha256 of a tag, the compressed public key, and the epoch as 8 big endian
+// bytes. Every signature by the same key at the same epoch has the same
+// nullifier, so storing nullifiers detects double signing with a single lookup.
+func Nullifier(curve CurveSystem, pubKey Point, epoch uint64) [32]byte {
+	requireCurve(curve, "Nullifier")
+	h := sha256.New()
+	h.Write(nullifierTag)
+	h.Write(pubKey.Marshal())
+	var epochBytes [8]byte
+	binary.BigEndian.PutUint64(epochBytes[:], epoch)
+	h.Write(epochBytes[:])
+	var nullifier [32]byte
+	copy(nullifier[:], h.Sum(nil))
+	return nullifier
+}
@@ -0,0 +1,26 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNullifier(t *testing.T) {
+	for _, curve := range curves {
+		_, vk, _ := KeyGen(curve)
+		_, vk2, _ := KeyGen(curve)
+		seen := make(map[[32]byte]bool)
+		for epoch := uint64(0); epoch < 100; epoch++ {
+			nullifier := Nullifier(curve, vk, epoch)
+			assert.Equal(t, nullifier, Nullifier(curve, vk.Copy(), epoch), "Nullifiers at the same epoch differ")
+			assert.False(t, seen[nullifier], "Nullifiers at different epochs collide")
+			seen[nullifier] = true
+			assert.NotEqual(t, nullifier, Nullifier(curve, vk2, epoch), "Nullifiers of different keys collide")
+		}
+		assert.NotEqual(t, Nullifier(curve, vk, 1), Nullifier(curve, vk, 1<<32))
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"time"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// CurveProfile holds the measured average duration of a curve's core operations.
+type CurveProfile struct {
+	Sign    time.Duration
+	Verify  time.Duration
+	Pairing time.Duration
+	G1Mul   time.Duration
+	G2Mul   time.Duration
+}
+
+// profileDuration is how long each operation is repeated for by ProfileCurve.
+const profileDuration = 200 * time.Millisecond
+
+// profileMinIterations is the least number of times ProfileCurve runs each operation.
+const profileMinIterations = 3
+
+// ProfileCurve measures the average duration of signing, verification, pairing,
+// and scalar multiplication in G1 and G2 on this machine. Each operation is
+// repeated for about 200 milliseconds, so profiling takes about a second.
+func ProfileCurve(curve CurveSystem) CurveProfile {
+	sk, vk, _ := KeyGen(curve)
+	msg := make([]byte, 64)
+	rand.Read(msg)
+	sig := Sign(curve, sk, msg)
+	return CurveProfile{
+		Sign:    measure(func() { Sign(curve, sk, msg) }),
+		Verify:  measure(func() { VerifySingleSignature(curve, sig, vk, msg) }),
+		Pairing: measure(func() { curve.Pair(sig, vk) }),
+		G1Mul:   measure(func() { curve.GetG1().Mul(sk) }),
+		G2Mul:   measure(func() { curve.GetG2().Mul(sk) }),
+	}
+}
+
+// MeasureAggregateVerify measures the average duration of verifying a distinct
+// message aggregate signature of each of the sizes, e.g. to choose batch sizes.
+// One key signs every message, which costs the verifier the same as distinct
+// keys. Each verification is repeated as in ProfileCurve, so this takes at least
+// 200 milliseconds per size. Sizes less than 1 are skipped.
+func MeasureAggregateVerify(curve CurveSystem, sizes []int) map[int]time.Duration {
+	sk, vk, _ := KeyGen(curve)
+	durations := make(map[int]time.Duration, len(sizes))
+	for _, size := range sizes {
+		if size < 1 {
+			continue
+		}
+		keys := make([]Point, size)
+		msgs := make([][]byte, size)
+		for i := 0; i < size; i++ {
+			keys[i] = vk
+			msgs[i] = make([]byte, 64)
+			rand.Read(msgs[i])
+		}
+		aggsig, _ := SignAndAggregate(curve, sk, msgs)
+		durations[size] = measure(func() { VerifyAggregateSignature(curve, aggsig, keys, msgs) })
+	}
+	return durations
+}
+
+// measure returns the average duration of op, repeating it for profileDuration.
+func measure(op func()) time.Duration {
+	start := time.Now()
+	iterations := 0
+	for iterations < profileMinIterations || time.Since(start) < profileDuration {
+		op()
+		iterations++
+	}
+	return time.Since(start) / time.Duration(iterations)
+}
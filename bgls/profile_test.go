@@ -0,0 +1,34 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileCurve(t *testing.T) {
+	for _, curve := range curves {
+		start := time.Now()
+		profile := ProfileCurve(curve)
+		assert.True(t, time.Since(start) < 3*time.Second, "Profiling took too long")
+		for _, d := range []time.Duration{profile.Sign, profile.Verify, profile.Pairing, profile.G1Mul, profile.G2Mul} {
+			assert.True(t, d > 0, "Profiled duration isn't positive")
+		}
+		assert.True(t, profile.Verify > profile.G1Mul, "Verification was faster than a G1 multiplication")
+	}
+}
+
+func TestMeasureAggregateVerify(t *testing.T) {
+	for _, curve := range curves {
+		durations := MeasureAggregateVerify(curve, []int{1, 4, 0})
+		assert.Equal(t, 2, len(durations), "Durations weren't measured for each positive size")
+		for _, size := range []int{1, 4} {
+			assert.True(t, durations[size] > 0, "Measured duration isn't positive")
+		}
+		assert.True(t, durations[4] > durations[1], "Verifying a larger aggregate was faster")
+	}
+}
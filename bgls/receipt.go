@@ -0,0 +1,55 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// SignReceipt is an auditable record that a key signed a message. It holds the
+// compressed public key, the message's hash to G1, and the signature, so it can be
+// serialized and checked by a third party with VerifyReceipt. The receipt is for
+// the message whose curve.HashToG1 marshals to MsgHash.
+type SignReceipt struct {
+	PubKey    []byte
+	MsgHash   []byte
+	Signature []byte
+}
+
+// SignWithReceipt creates a standard BLS signature on a message, and a receipt
+// recording the signature, the signer's public key and the message's hash.
+func SignWithReceipt(curve CurveSystem, sk *big.Int, msg []byte) (Point, SignReceipt) {
+	requireCurve(curve, "SignWithReceipt")
+	h := curve.HashToG1(msg)
+	sig := h.Mul(sk)
+	receipt := SignReceipt{
+		PubKey:    LoadPublicKey(curve, sk).Marshal(),
+		MsgHash:   h.Marshal(),
+		Signature: sig.Marshal(),
+	}
+	return sig, receipt
+}
+
+// VerifyReceipt checks that the receipt's signature is valid for its message
+// hash and public key. This doesn't need the message itself, so to check which
+// message the receipt is for, compare MsgHash with the marshalled hash of the
+// message.
+func VerifyReceipt(curve CurveSystem, receipt SignReceipt) bool {
+	requireCurve(curve, "VerifyReceipt")
+	pubKey, rest, ok := readPoint(curve.UnmarshalG2, curve.GetG2(), receipt.PubKey)
+	if !ok || len(rest) != 0 {
+		return false
+	}
+	h, rest, ok := readPoint(curve.UnmarshalG1, curve.GetG1(), receipt.MsgHash)
+	if !ok || len(rest) != 0 {
+		return false
+	}
+	sig, rest, ok := readPoint(curve.UnmarshalG1, curve.GetG1(), receipt.Signature)
+	if !ok || len(rest) != 0 {
+		return false
+	}
+	return VerifySingleSignatureCustHash(curve, sig, pubKey, nil, func([]byte) Point { return h })
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignWithReceipt(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		sig, receipt := SignWithReceipt(curve, sk, msg)
+		assert.True(t, VerifySingleSignature(curve, sig, vk, msg), "Signature with receipt failed verification")
+		assert.True(t, VerifyReceipt(curve, receipt), "Receipt failed verification")
+		assert.True(t, bytes.Equal(receipt.MsgHash, curve.HashToG1(msg).Marshal()))
+
+		data, err := json.Marshal(receipt)
+		assert.Nil(t, err)
+		var decoded SignReceipt
+		assert.Nil(t, json.Unmarshal(data, &decoded))
+		assert.True(t, VerifyReceipt(curve, decoded), "Serialized receipt failed verification")
+
+		_, vk2, _ := KeyGen(curve)
+		tampered := decoded
+		tampered.PubKey = vk2.Marshal()
+		assert.False(t, VerifyReceipt(curve, tampered), "Receipt with the wrong key passed verification")
+		tampered = decoded
+		tampered.MsgHash = curve.HashToG1(msg[1:]).Marshal()
+		assert.False(t, VerifyReceipt(curve, tampered), "Receipt with the wrong message passed verification")
+		tampered = decoded
+		tampered.Signature = append([]byte{}, receipt.Signature...)
+		tampered.Signature[len(tampered.Signature)-1] ^= 1
+		assert.False(t, VerifyReceipt(curve, tampered), "Receipt with a tampered signature passed verification")
+		tampered.Signature = receipt.Signature[1:]
+		assert.False(t, VerifyReceipt(curve, tampered), "Receipt with a truncated signature passed verification")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"errors"
+	"sync"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// ErrUnknownKeyID is returned when an id isn't registered in a KeyRegistry.
+var ErrUnknownKeyID = errors.New("key id isn't registered")
+
+// KeyRegistry assigns small integer ids to public keys, so that aggregates can
+// reference their signers by id rather than by key. Ids are assigned in order
+// of registration, starting from 0. It is safe for concurrent use.
+type KeyRegistry struct {
+	mu   sync.RWMutex
+	keys []Point
+	ids  map[string]uint32
+}
+
+// NewKeyRegistry creates an empty KeyRegistry.
+func NewKeyRegistry() *KeyRegistry {
+	return &KeyRegistry{ids: make(map[string]uint32)}
+}
+
+// Register adds key to the registry and returns its id. Registering a key again
+// returns the id it was first registered with.
+func (r *KeyRegistry) Register(key Point) uint32 {
+	encoded := string(key.Marshal())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.ids[encoded]; ok {
+		return id
+	}
+	id := uint32(len(r.keys))
+	r.keys = append(r.keys, key)
+	r.ids[encoded] = id
+	return id
+}
+
+// Lookup returns the key registered with id, or false if there is none.
+func (r *KeyRegistry) Lookup(id uint32) (Point, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if uint64(id) >= uint64(len(r.keys)) {
+		return nil, false
+	}
+	return r.keys[id], true
+}
+
+// VerifyAggregateByIDs checks an aggregate signature as VerifyAggregateSignature
+// does, where msgs[i] was signed by the key registered with ids[i]. It returns
+// ErrUnknownKeyID if any id isn't registered, and ErrLengthMismatch if the ids and
+// messages differ in number.
+func VerifyAggregateByIDs(curve CurveSystem, registry *KeyRegistry, aggsig Point, ids []uint32,
+	msgs [][]byte) (bool, error) {
+	if len(ids) != len(msgs) {
+		return false, ErrLengthMismatch
+	}
+	keys := make([]Point, len(ids))
+	for i, id := range ids {
+		key, ok := registry.Lookup(id)
+		if !ok {
+			return false, ErrUnknownKeyID
+		}
+		keys[i] = key
+	}
+	return VerifyAggregateSignature(curve, aggsig, keys, msgs), nil
+}
@@ -0,0 +1,53 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRegistry(t *testing.T) {
+	for _, curve := range curves {
+		N, Size := 5, 32
+		registry := NewKeyRegistry()
+		keys := make([]Point, N)
+		sigs := make([]Point, N)
+		msgs := make([][]byte, N)
+		ids := make([]uint32, N)
+		for i := 0; i < N; i++ {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			ids[i] = registry.Register(vk)
+			assert.Equal(t, uint32(i), ids[i])
+			msgs[i] = make([]byte, Size)
+			rand.Read(msgs[i])
+			sigs[i] = Sign(curve, sk, msgs[i])
+		}
+		assert.Equal(t, ids[2], registry.Register(keys[2].Copy()), "Registering a key again changed its id")
+		key, ok := registry.Lookup(ids[3])
+		assert.True(t, ok && key.Equals(keys[3]))
+		_, ok = registry.Lookup(uint32(N))
+		assert.False(t, ok, "Unregistered id was found")
+
+		// Verify an aggregate of signers 4, 1 and 2
+		signers := []uint32{ids[4], ids[1], ids[2]}
+		signerMsgs := [][]byte{msgs[4], msgs[1], msgs[2]}
+		aggsig := AggregateSignatures([]Point{sigs[4], sigs[1], sigs[2]})
+		ok, err := VerifyAggregateByIDs(curve, registry, aggsig, signers, signerMsgs)
+		assert.Nil(t, err)
+		assert.True(t, ok, "Aggregate by ids failed verification")
+		ok, err = VerifyAggregateByIDs(curve, registry, aggsig, []uint32{ids[4], ids[1], ids[3]}, signerMsgs)
+		assert.Nil(t, err)
+		assert.False(t, ok, "Aggregate verified with the wrong ids")
+
+		_, err = VerifyAggregateByIDs(curve, registry, aggsig, []uint32{ids[4], ids[1], 1000}, signerMsgs)
+		assert.Equal(t, ErrUnknownKeyID, err)
+		_, err = VerifyAggregateByIDs(curve, registry, aggsig, signers, signerMsgs[1:])
+		assert.Equal(t, ErrLengthMismatch, err)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"math/big"
+	"sync"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// scalarSampleSize is the number of random bytes reduced into each scalar. It is
+// twice the size of the group order, so the bias of the reduction is negligible.
+const scalarSampleSize = 64
+
+// ScalarSampler draws uniformly random nonzero scalars modulo the group order,
+// e.g. for the weights of batch verification. It is a CSPRNG, AES-256 in counter
+// mode keyed from crypto/rand, so drawing a scalar costs no system calls. It is
+// safe for concurrent use.
+type ScalarSampler struct {
+	mu            sync.Mutex
+	stream        cipher.Stream
+	orderMinusOne *big.Int
+}
+
+// NewScalarSampler creates a ScalarSampler for scalars modulo the curve's group
+// order, seeded from crypto/rand.
+func NewScalarSampler(curve CurveSystem) (*ScalarSampler, error) {
+	if curve == nil {
+		return nil, ErrNilCurve
+	}
+	seed := make([]byte, 32+aes.BlockSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(seed[:32])
+	if err != nil {
+		return nil, err
+	}
+	return &ScalarSampler{
+		stream:        cipher.NewCTR(block, seed[32:]),
+		orderMinusOne: new(big.Int).Sub(curve.GetG1Order(), big.NewInt(1)),
+	}, nil
+}
+
+// Next returns a uniformly random scalar in [1, order-1].
+func (s *ScalarSampler) Next() *big.Int {
+	var buf [scalarSampleSize]byte
+	s.mu.Lock()
+	s.stream.XORKeyStream(buf[:], buf[:])
+	s.mu.Unlock()
+	k := new(big.Int).SetBytes(buf[:])
+	return k.Mod(k, s.orderMinusOne).Add(k, big.NewInt(1))
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScalarSampler(t *testing.T) {
+	for _, curve := range curves {
+		sampler, err := NewScalarSampler(curve)
+		assert.Nil(t, err)
+		seen := make(map[string]bool)
+		for i := 0; i < 10000; i++ {
+			k := sampler.Next()
+			assert.True(t, k.Sign() > 0 && k.Cmp(curve.GetG1Order()) < 0, "Scalar out of range")
+			seen[k.String()] = true
+		}
+		assert.Equal(t, 10000, len(seen), "Sampler repeated a scalar")
+
+		other, _ := NewScalarSampler(curve)
+		assert.NotEqual(t, 0, sampler.Next().Cmp(other.Next()), "Samplers aren't independently seeded")
+
+		// Concurrent draws are safe
+		var wg sync.WaitGroup
+		wg.Add(4)
+		for w := 0; w < 4; w++ {
+			go func() {
+				for i := 0; i < 100; i++ {
+					sampler.Next()
+				}
+				wg.Done()
+			}()
+		}
+		wg.Wait()
+	}
+	_, err := NewScalarSampler(nil)
+	assert.Equal(t, ErrNilCurve, err)
+}
+
+func BenchmarkScalarSampler(b *testing.B) {
+	sampler, _ := NewScalarSampler(benchmarkCurve)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sampler.Next()
+	}
+}
+
+func BenchmarkScalarCryptoRand(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		rand.Int(rand.Reader, benchmarkCurve.GetG1Order())
+	}
+}
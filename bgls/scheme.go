@@ -0,0 +1,47 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements scheme tagged types for the min-sig scheme used by this
+// library, where signatures are in G1 and public keys are in G2. A signature and
+// a public key have distinct types, so passing one in place of the other, or a
+// point from a peer using another scheme, fails to compile rather than failing
+// verification. The min-pubkey scheme, with signatures in G2, would need a hash
+// to G2, which the curves don't provide, so it has no types here.
+
+import (
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// MinSigSignature is a min-sig scheme signature, which is in G1.
+type MinSigSignature struct {
+	Point
+}
+
+// MinSigPublicKey is a min-sig scheme public key, which is in G2.
+type MinSigPublicKey struct {
+	Point
+}
+
+// MinSigKeyGen generates a secret key and its min-sig public key.
+func MinSigKeyGen(curve CurveSystem) (*big.Int, MinSigPublicKey, error) {
+	sk, pubKey, err := KeyGen(curve)
+	return sk, MinSigPublicKey{pubKey}, err
+}
+
+// SignMinSig creates a standard BLS signature on a message with a private key,
+// tagged as a min-sig signature.
+func SignMinSig(curve CurveSystem, sk *big.Int, msg []byte) MinSigSignature {
+	return MinSigSignature{Sign(curve, sk, msg)}
+}
+
+// VerifyMinSig checks that sig is a min-sig signature on msg by pubKey.
+func VerifyMinSig(curve CurveSystem, pubKey MinSigPublicKey, msg []byte, sig MinSigSignature) bool {
+	if pubKey.Point == nil || sig.Point == nil {
+		return false
+	}
+	return VerifySingleSignature(curve, sig.Point, pubKey.Point, msg)
+}
@@ -0,0 +1,32 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinSigScheme(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, err := MinSigKeyGen(curve)
+		assert.Nil(t, err)
+		msg := []byte("message")
+		sig := SignMinSig(curve, sk, msg)
+		assert.True(t, VerifyMinSig(curve, vk, msg, sig), "Min-sig signature failed verification")
+		assert.True(t, VerifySingleSignature(curve, sig.Point, vk.Point, msg))
+		assert.False(t, VerifyMinSig(curve, vk, []byte("other"), sig))
+		assert.False(t, VerifyMinSig(curve, MinSigPublicKey{}, msg, sig))
+	}
+	// Signatures, public keys and plain points can't be used in place of each other.
+	sigType := reflect.TypeOf(MinSigSignature{})
+	keyType := reflect.TypeOf(MinSigPublicKey{})
+	pointType := reflect.TypeOf((*Point)(nil)).Elem()
+	assert.False(t, sigType.AssignableTo(keyType), "A min-sig signature can be used as a public key")
+	assert.False(t, keyType.AssignableTo(sigType), "A min-sig public key can be used as a signature")
+	assert.False(t, pointType.AssignableTo(sigType), "A plain point can be used as a min-sig signature")
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// VerifyScratch holds the pairing inputs used by VerifySingleSignatureScratch,
+// so that they're allocated once and reused across verifications. A
+// VerifyScratch must not be used by more than one goroutine at a time, so
+// concurrent verifiers each need their own.
+type VerifyScratch struct {
+	pairs [2]PairInput
+}
+
+// NewVerifyScratch allocates the scratch space for VerifySingleSignatureScratch.
+func NewVerifyScratch() *VerifyScratch {
+	return &VerifyScratch{}
+}
+
+// VerifySingleSignatureScratch checks that a single standard BLS signature is
+// valid, as VerifySingleSignature does, using the caller's scratch space for the
+// pairing inputs. Both pairings are computed by one Miller loop on the calling
+// goroutine, followed by a single final exponentiation. This only saves the
+// input slice: the hashing and pairing arithmetic of altbn128 allocate on every
+// call, so this allocates about as much as VerifySingleSignature.
+func VerifySingleSignatureScratch(curve CurveSystem, pubKey Point, msg []byte, sig Point,
+	scratch *VerifyScratch) bool {
+	requireCurve(curve, "VerifySingleSignatureScratch")
+	if CheckCurve(curve, sig, pubKey) != nil {
+		return false
+	}
+	scratch.pairs[0] = PairInput{G1: curve.HashToG1(msg).Neg(), G2: pubKey}
+	scratch.pairs[1] = PairInput{G1: sig, G2: curve.GetG2()}
+	product, ok := curve.MultiMillerLoop(scratch.pairs[:])
+	scratch.pairs = [2]PairInput{}
+	if !ok {
+		return false
+	}
+	product = curve.FinalExponentiation(product)
+	return product != nil && product.Equals(curve.GetGTIdentity())
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySingleSignatureScratch(t *testing.T) {
+	scratch := NewVerifyScratch()
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		_, vk2, _ := KeyGen(curve)
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		sig := Sign(curve, sk, msg)
+		// The scratch space is reused across verifications.
+		for i := 0; i < 3; i++ {
+			assert.True(t, VerifySingleSignatureScratch(curve, vk, msg, sig, scratch),
+				"Signature failed verification with scratch space")
+			assert.False(t, VerifySingleSignatureScratch(curve, vk2, msg, sig, scratch),
+				"Signature passed verification with the wrong key")
+			assert.False(t, VerifySingleSignatureScratch(curve, vk, msg[1:], sig, scratch),
+				"Signature passed verification on the wrong message")
+		}
+	}
+}
+
+func BenchmarkVerificationScratch(b *testing.B) {
+	sk, vk, _ := KeyGen(benchmarkCurve)
+	msg := make([]byte, 64)
+	rand.Read(msg)
+	sig := Sign(benchmarkCurve, sk, msg)
+	scratch := NewVerifyScratch()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		VerifySingleSignatureScratch(benchmarkCurve, vk, msg, sig, scratch)
+	}
+}
+
+// BenchmarkVerificationNoScratch is the baseline for BenchmarkVerificationScratch,
+// run with -benchmem to compare their allocations.
+func BenchmarkVerificationNoScratch(b *testing.B) {
+	sk, vk, _ := KeyGen(benchmarkCurve)
+	msg := make([]byte, 64)
+	rand.Read(msg)
+	sig := Sign(benchmarkCurve, sk, msg)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		VerifySingleSignature(benchmarkCurve, sig, vk, msg)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file allows signing with keys which are held externally, e.g. in an HSM,
+// and never appear in process memory. The message is hashed onto the curve
+// locally, and the Signer only has to scale the hashed point by its secret key.
+
+import (
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// Signer is a secret key which is able to sign already hashed messages.
+type Signer interface {
+	// Public returns the public key corresponding to the secret key.
+	Public() Point
+	// Sign returns the hashed message scaled by the secret key.
+	Sign(hashedMsg Point) Point
+}
+
+// SignWithSigner creates a standard BLS signature on a message with the signer.
+func SignWithSigner(curve CurveSystem, signer Signer, msg []byte) Point {
+	return SignWithSignerCustHash(signer, msg, curve.HashToG1)
+}
+
+// SignWithSignerCustHash creates a standard BLS signature on a message with the
+// signer, using a supplied function to hash onto the curve where signatures lie.
+func SignWithSignerCustHash(signer Signer, msg []byte, hash func([]byte) Point) Point {
+	return signer.Sign(hash(msg))
+}
+
+// SignBatchWithSigner creates standard BLS signatures on each of the messages
+// with the signer, as in SignBatch. The signer must be safe for concurrent use.
+func SignBatchWithSigner(curve CurveSystem, signer Signer, msgs [][]byte) []Point {
+	return signBatch(len(msgs), func(i int) Point { return SignWithSigner(curve, signer, msgs[i]) })
+}
+
+// KoskSignWithSigner creates a kosk signature on a message with the signer.
+func KoskSignWithSigner(curve CurveSystem, signer Signer, msg []byte) Point {
+	return KoskSignWithSignerCustHash(curve, signer, msg, curve.HashToG1)
+}
+
+// KoskSignWithSignerCustHash creates a kosk signature on a message with the
+// signer, using a supplied function to hash to point.
+func KoskSignWithSignerCustHash(curve CurveSystem, signer Signer, msg []byte, hash func([]byte) Point) Point {
+	m := append([]byte{1}, msg...)
+	return SignWithSignerCustHash(signer, m, hash)
+}
+
+// AuthenticateWithSigner generates an Aggregatable Authentication for the
+// signer's key. See Authenticate.
+func AuthenticateWithSigner(curve CurveSystem, signer Signer) Point {
+	return AuthenticateWithSignerCustHash(curve, signer, curve.HashToG1)
+}
+
+// AuthenticateWithSignerCustHash generates an Aggregatable Authentication for
+// the signer's key, using the specified hash function.
+func AuthenticateWithSignerCustHash(curve CurveSystem, signer Signer, hash func([]byte) Point) Point {
+	msg := signer.Public().Marshal()
+	msg = append(make([]byte, 0), msg...)
+	return SignWithSignerCustHash(signer, msg, hash)
+}
+
+// DistinctMsgSignWithSigner creates a 'Distinct Message' signature on a message
+// with the signer, prepending the signer's public key to the message.
+func DistinctMsgSignWithSigner(curve CurveSystem, signer Signer, msg []byte) Point {
+	return DistinctMsgSignWithSignerCustHash(curve, signer, msg, curve.HashToG1)
+}
+
+// DistinctMsgSignWithSignerCustHash creates a 'Distinct Message' signature on a
+// message with the signer, using a supplied function to hash to g1.
+func DistinctMsgSignWithSignerCustHash(curve CurveSystem, signer Signer, msg []byte, hash func([]byte) Point) Point {
+	m := append(signer.Public().MarshalUncompressed(), msg...)
+	return SignWithSignerCustHash(signer, m, hash)
+}
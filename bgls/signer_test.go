@@ -0,0 +1,79 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+type localSigner struct {
+	curve CurveSystem
+	sk    *big.Int
+}
+
+func (s *localSigner) Public() Point {
+	return LoadPublicKey(s.curve, s.sk)
+}
+
+func (s *localSigner) Sign(hashedMsg Point) Point {
+	return hashedMsg.Mul(s.sk)
+}
+
+func TestSigner(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, _ := KeyGen(curve)
+		signer := &localSigner{curve, sk}
+		assert.True(t, signer.Public().Equals(vk))
+		msg := make([]byte, 64)
+		rand.Read(msg)
+
+		sig := SignWithSigner(curve, signer, msg)
+		assert.True(t, sig.Equals(Sign(curve, sk, msg)), "Signer output differs from Sign")
+		assert.True(t, VerifySingleSignature(curve, sig, vk, msg), "Signer signature verification failed")
+
+		sig = KoskSignWithSigner(curve, signer, msg)
+		assert.True(t, sig.Equals(KoskSign(curve, sk, msg)), "Signer output differs from KoskSign")
+		assert.True(t, KoskVerifySingleSignature(curve, sig, vk, msg))
+
+		auth := AuthenticateWithSigner(curve, signer)
+		assert.True(t, auth.Equals(Authenticate(curve, sk)), "Signer output differs from Authenticate")
+		assert.True(t, CheckAuthentication(curve, vk, auth))
+
+		sig = DistinctMsgSignWithSigner(curve, signer, msg)
+		assert.True(t, sig.Equals(DistinctMsgSign(curve, sk, msg)), "Signer output differs from DistinctMsgSign")
+		assert.True(t, DistinctMsgVerifySingleSignature(curve, sig, vk, msg))
+
+		hash := func(m []byte) Point { return curve.HashToG1WithHash(m, []byte("BGLS_TEST_DST"), sha256.New) }
+		assert.True(t, KoskSignWithSignerCustHash(curve, signer, msg, hash).Equals(KoskSignCustHash(curve, sk, msg, hash)),
+			"Signer output differs from KoskSignCustHash")
+		assert.True(t, AuthenticateWithSignerCustHash(curve, signer, hash).Equals(AuthenticateCustHash(curve, sk, hash)),
+			"Signer output differs from AuthenticateCustHash")
+		assert.True(t, DistinctMsgSignWithSignerCustHash(curve, signer, msg, hash).Equals(
+			DistinctMsgSignCustHash(curve, sk, msg, hash)), "Signer output differs from DistinctMsgSignCustHash")
+	}
+}
+
+func TestSignBatchWithSigner(t *testing.T) {
+	for _, curve := range curves {
+		sk, _, _ := KeyGen(curve)
+		signer := &localSigner{curve, sk}
+		msgs := make([][]byte, 20)
+		for i := 0; i < len(msgs); i++ {
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+		}
+		sigs := SignBatchWithSigner(curve, signer, msgs)
+		assert.Equal(t, len(msgs), len(sigs))
+		for i := 0; i < len(msgs); i++ {
+			assert.True(t, sigs[i].Equals(Sign(curve, sk, msgs[i])), "Batch signature with signer differs from Sign")
+		}
+		assert.Equal(t, 0, len(SignBatchWithSigner(curve, signer, [][]byte{})))
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements stake weighted signature collection, as used in
+// consensus, where signatures are gathered until the signers hold enough stake.
+
+import (
+	"errors"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// ErrDuplicateSigner is returned when a key is added to a StakeAggregator twice.
+var ErrDuplicateSigner = errors.New("key has already been added")
+
+// ErrInvalidWeight is returned when a signer's weight is nil or negative.
+var ErrInvalidWeight = errors.New("weight must be non negative")
+
+// ErrInvalidSignature is returned when a signature added to a StakeAggregator
+// doesn't verify.
+var ErrInvalidSignature = errors.New("signature doesn't verify")
+
+// StakeAggregator aggregates standard BLS signatures as they are collected, and
+// tracks the total weight of their signers. Each signature is verified as it is
+// added, so an invalid signature is rejected without blocking the aggregate.
+type StakeAggregator struct {
+	curve     CurveSystem
+	threshold *big.Int
+	weight    *big.Int
+	sig       Point
+	signers   map[string]bool
+}
+
+// NewStakeAggregator creates a StakeAggregator which is ready once the total
+// weight of its signers is at least threshold. It returns ErrInvalidWeight if
+// threshold is nil or negative.
+func NewStakeAggregator(curve CurveSystem, threshold *big.Int) (*StakeAggregator, error) {
+	if curve == nil {
+		return nil, ErrNilCurve
+	}
+	if threshold == nil || threshold.Sign() < 0 {
+		return nil, ErrInvalidWeight
+	}
+	return &StakeAggregator{
+		curve:     curve,
+		threshold: new(big.Int).Set(threshold),
+		weight:    new(big.Int),
+		signers:   make(map[string]bool),
+	}, nil
+}
+
+// Add verifies key's signature sig on msg, and if it is valid, adds it with the
+// key's weight. An invalid signature returns ErrInvalidSignature, and leaves the
+// aggregator unchanged.
+func (s *StakeAggregator) Add(key Point, weight *big.Int, msg []byte, sig Point) error {
+	if weight == nil || weight.Sign() < 0 {
+		return ErrInvalidWeight
+	}
+	if err := CheckCurve(s.curve, key, sig); err != nil {
+		return err
+	}
+	id := string(key.Marshal())
+	if s.signers[id] {
+		return ErrDuplicateSigner
+	}
+	if !VerifySingleSignature(s.curve, sig, key, msg) {
+		return ErrInvalidSignature
+	}
+	s.signers[id] = true
+	s.weight.Add(s.weight, weight)
+	if s.sig == nil {
+		s.sig = sig
+	} else {
+		s.sig, _ = s.sig.Add(sig)
+	}
+	return nil
+}
+
+// Weight returns the total weight of the signers added so far.
+func (s *StakeAggregator) Weight() *big.Int {
+	return new(big.Int).Set(s.weight)
+}
+
+// Signature returns the aggregate of the signatures added so far, or nil if
+// none have been added.
+func (s *StakeAggregator) Signature() Point {
+	return s.sig
+}
+
+// Ready checks that a signature has been added, and that the total weight of
+// the signers is at least the threshold. The aggregate is valid, since each
+// signature was verified when it was added.
+func (s *StakeAggregator) Ready() bool {
+	return s.sig != nil && s.weight.Cmp(s.threshold) >= 0
+}
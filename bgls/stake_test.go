@@ -0,0 +1,61 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStakeAggregator(t *testing.T) {
+	for _, curve := range curves {
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		weights := []int64{10, 20, 30, 40}
+		// Two thirds of the total stake of 100
+		agg, err := NewStakeAggregator(curve, big.NewInt(67))
+		assert.Nil(t, err)
+		assert.False(t, agg.Ready(), "Empty aggregator is ready")
+		keys := make([]Point, len(weights))
+		for i, w := range weights {
+			sk, vk, _ := KeyGen(curve)
+			keys[i] = vk
+			assert.Nil(t, agg.Add(vk, big.NewInt(w), msg, Sign(curve, sk, msg)))
+			assert.Equal(t, i == len(weights)-1, agg.Ready(), "Aggregator readiness is wrong after signer %d", i)
+		}
+		assert.Equal(t, int64(100), agg.Weight().Int64())
+		assert.True(t, VerifySingleSignature(curve, agg.Signature(), AggregateKeys(keys), msg))
+
+		assert.Equal(t, ErrDuplicateSigner, agg.Add(keys[0], big.NewInt(10), msg, curve.GetG1()),
+			"Added the same signer twice")
+		assert.Equal(t, ErrInvalidWeight, agg.Add(curve.GetG2(), big.NewInt(-1), msg, curve.GetG1()))
+		assert.True(t, agg.Ready())
+
+		// Signers may sign different messages
+		msg2 := make([]byte, 32)
+		rand.Read(msg2)
+		sk, vk, _ := KeyGen(curve)
+		assert.Nil(t, agg.Add(vk, big.NewInt(5), msg2, Sign(curve, sk, msg2)))
+		assert.True(t, agg.Ready(), "Aggregator over two messages isn't ready")
+
+		// An invalid signature is rejected without blocking later signers
+		agg, _ = NewStakeAggregator(curve, big.NewInt(1))
+		sk, vk, _ = KeyGen(curve)
+		assert.Equal(t, ErrInvalidSignature, agg.Add(vk, big.NewInt(1), msg, Sign(curve, sk, msg2)))
+		assert.False(t, agg.Ready(), "Aggregator with only an invalid signature is ready")
+		assert.Zero(t, agg.Weight().Sign(), "Invalid signature added weight")
+		assert.Nil(t, agg.Add(vk, big.NewInt(1), msg, Sign(curve, sk, msg)), "Signer rejected after an invalid signature")
+		assert.True(t, agg.Ready(), "Aggregator isn't ready after an invalid signature")
+	}
+	_, err := NewStakeAggregator(curves[0], nil)
+	assert.Equal(t, ErrInvalidWeight, err)
+	_, err = NewStakeAggregator(curves[0], big.NewInt(-1))
+	assert.Equal(t, ErrInvalidWeight, err)
+	_, err = NewStakeAggregator(nil, big.NewInt(1))
+	assert.Equal(t, ErrNilCurve, err)
+}
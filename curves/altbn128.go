@@ -5,7 +5,9 @@ package curves
 
 import (
 	"bytes"
+	"hash"
 	"math/big"
+	"runtime"
 
 	"github.com/dchest/blake2b"
 	"github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
@@ -36,6 +38,20 @@ func (curve *altbn128) Name() string {
 	return "altbn128"
 }
 
+// Parameters returns altbn128's parameters. Its security level was originally
+// estimated at 128 bits, but the extended tower number field sieve reduces
+// this to about 100 bits.
+func (curve *altbn128) Parameters() CurveParameters {
+	return CurveParameters{
+		Name:            curve.Name(),
+		EmbeddingDegree: 12,
+		G1Size:          32,
+		G2Size:          64,
+		ScalarBits:      altbnG1Order.BitLen(),
+		SecurityBits:    100,
+	}
+}
+
 // MakeG1Point copies points into []byte and unmarshals to get around curvePoint not being exported
 // Check does nothing here, because the upstream library always
 // ensures that the point is on the curve.
@@ -71,6 +87,16 @@ func (g1Point *altbn128Point1) Copy() Point {
 	return &altbn128Point1{result}
 }
 
+// Curve returns Altbn128.
+func (g1Point *altbn128Point1) Curve() CurveSystem {
+	return Altbn128
+}
+
+// Cmp compares the compressed encodings of the two points lexicographically.
+func (g1Point *altbn128Point1) Cmp(otherPoint Point) int {
+	return bytes.Compare(g1Point.Marshal(), otherPoint.Marshal())
+}
+
 func (g1Point *altbn128Point1) Equals(otherPoint1 Point) bool {
 	if other, ok := (otherPoint1).(*altbn128Point1); ok {
 		return bytes.Equal(g1Point.point.Marshal(), other.point.Marshal())
@@ -78,6 +104,31 @@ func (g1Point *altbn128Point1) Equals(otherPoint1 Point) bool {
 	return false
 }
 
+// InCorrectSubgroup always returns true, since the cofactor of G1 is 1 and
+// the upstream library ensures that all points are on the curve.
+func (g1Point *altbn128Point1) InCorrectSubgroup() bool {
+	return true
+}
+
+// IsOnCurve checks that the point's affine coordinates satisfy y^2 = x^3 + 3.
+// The point at infinity is on the curve.
+func (g1Point *altbn128Point1) IsOnCurve() bool {
+	coords := g1Point.ToAffineCoords()
+	return altbnG1IsOnCurve(coords[0], coords[1])
+}
+
+func altbnG1IsOnCurve(x, y *big.Int) bool {
+	if x.Cmp(altbnG1Q) >= 0 || y.Cmp(altbnG1Q) >= 0 {
+		return false
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return true
+	}
+	ySqr := new(big.Int).Exp(y, two, altbnG1Q)
+	rhs := Altbn128.g1XToYSquared(x)
+	return ySqr.Cmp(rhs.Mod(rhs, altbnG1Q)) == 0
+}
+
 func (g1Point *altbn128Point1) Marshal() []byte {
 	coords := g1Point.ToAffineCoords()
 	xBytes := pad32Bytes(coords[0].Bytes())
@@ -104,11 +155,15 @@ func pad32Bytes(xBytes []byte) []byte {
 	return xBytes
 }
 
+func (g1Point *altbn128Point1) MulReduced(scalar *big.Int) Point {
+	return g1Point.Mul(new(big.Int).Mod(scalar, altbnG1Order))
+}
+
 func (g1Point *altbn128Point1) Mul(scalar *big.Int) Point {
 	scalar2 := new(big.Int)
 	cmp := scalar.Cmp(zero)
 	if cmp < 0 {
-		g1Point = g1Point.Negate()
+		g1Point = g1Point.Neg().(*altbn128Point1)
 		scalar2.Mul(scalar, big.NewInt(-1))
 	} else if cmp == 0 {
 		return Altbn128.GetG1Infinity()
@@ -120,11 +175,9 @@ func (g1Point *altbn128Point1) Mul(scalar *big.Int) Point {
 	return ret
 }
 
-func (g1Point *altbn128Point1) Negate() *altbn128Point1 {
-	coords := g1Point.ToAffineCoords()
-	coords[1].Sub(altbnG1Q, coords[1])
-	newPt, _ := Altbn128.MakeG1Point(coords, false)
-	return newPt.(*altbn128Point1)
+// Neg returns the additive inverse of the point.
+func (g1Point *altbn128Point1) Neg() Point {
+	return &altbn128Point1{new(bn256.G1).Neg(g1Point.point)}
 }
 
 func (curve *altbn128) Pair(g1Point Point, g2Point Point) (PointT, bool) {
@@ -140,8 +193,87 @@ func (curve *altbn128) Pair(g1Point Point, g2Point Point) (PointT, bool) {
 	return nil, false
 }
 
+// PairingProduct computes the product of the pairings with a single final
+// exponentiation, using MultiMillerLoop.
 func (curve *altbn128) PairingProduct(g1Points []Point, g2Points []Point) (PointT, bool) {
-	return concurrentPairingProduct(curve, g1Points, g2Points)
+	if len(g1Points) != len(g2Points) {
+		return nil, false
+	}
+	pairs := make([]PairInput, len(g1Points))
+	for i := 0; i < len(pairs); i++ {
+		pairs[i] = PairInput{g1Points[i], g2Points[i]}
+	}
+	product, ok := curve.MultiMillerLoop(pairs)
+	if !ok {
+		return nil, false
+	}
+	return curve.FinalExponentiation(product), true
+}
+
+// sequentialMillerLoops is the largest number of pairs whose Miller loops are
+// computed inline, as for a single signature's two pairings the cost of
+// starting workers outweighs the gain.
+const sequentialMillerLoops = 2
+
+// MultiMillerLoop computes the Miller loops of the pairs across a pool of
+// workers, where each worker multiplies the Miller loops of its share of the
+// pairs, and then multiplies the workers' products. Up to sequentialMillerLoops
+// pairs are computed inline, without any workers.
+func (curve *altbn128) MultiMillerLoop(pairs []PairInput) (PointT, bool) {
+	g1Points := make([]*bn256.G1, len(pairs))
+	g2Points := make([]*bn256.G2, len(pairs))
+	for i := 0; i < len(pairs); i++ {
+		pt1, ok1 := pairs[i].G1.(*altbn128Point1)
+		pt2, ok2 := pairs[i].G2.(*altbn128Point2)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		g1Points[i], g2Points[i] = pt1.point, pt2.point
+	}
+	if len(pairs) == 0 {
+		return altbn128PointT{new(bn256.GT).Set(altbnGTIdentity.(altbn128PointT).point)}, true
+	}
+	if len(pairs) <= sequentialMillerLoops {
+		return altbn128PointT{millerLoops(g1Points, g2Points, 0, 1)}, true
+	}
+	workers := runtime.NumCPU()
+	if workers > len(pairs) {
+		workers = len(pairs)
+	}
+	c := make(chan *bn256.GT, workers)
+	for w := 0; w < workers; w++ {
+		go concurrentMillerLoops(g1Points, g2Points, w, workers, c)
+	}
+	product := new(bn256.GT).Set(altbnGTIdentity.(altbn128PointT).point)
+	for w := 0; w < workers; w++ {
+		product.Add(product, <-c)
+	}
+	return altbn128PointT{product}, true
+}
+
+// concurrentMillerLoops sends the product of millerLoops down the channel.
+func concurrentMillerLoops(g1Points []*bn256.G1, g2Points []*bn256.G2, start int, step int, c chan *bn256.GT) {
+	c <- millerLoops(g1Points, g2Points, start, step)
+}
+
+// millerLoops multiplies the Miller loops of the pairs at indices start,
+// start + step, ... There must be at least one such pair.
+func millerLoops(g1Points []*bn256.G1, g2Points []*bn256.G2, start int, step int) *bn256.GT {
+	product := bn256.Miller(g1Points[start], g2Points[start])
+	for i := start + step; i < len(g1Points); i += step {
+		product.Add(product, bn256.Miller(g1Points[i], g2Points[i]))
+	}
+	return product
+}
+
+// FinalExponentiation maps the output of MultiMillerLoop into GT. It returns
+// nil if the element isn't from altbn128.
+func (curve *altbn128) FinalExponentiation(pt PointT) PointT {
+	gTPoint, ok := pt.(altbn128PointT)
+	if !ok {
+		return nil
+	}
+	return altbn128PointT{new(bn256.GT).Set(gTPoint.point).Finalize()}
 }
 
 // ToAffineCoords returns the affine coordinate representation of the point
@@ -193,6 +325,16 @@ func (g2Point *altbn128Point2) Copy() Point {
 	return &altbn128Point2{result}
 }
 
+// Curve returns Altbn128.
+func (g2Point *altbn128Point2) Curve() CurveSystem {
+	return Altbn128
+}
+
+// Cmp compares the compressed encodings of the two points lexicographically.
+func (g2Point *altbn128Point2) Cmp(otherPoint Point) int {
+	return bytes.Compare(g2Point.Marshal(), otherPoint.Marshal())
+}
+
 func (g2Point *altbn128Point2) Equals(otherPoint2 Point) bool {
 	if other, ok := (otherPoint2).(*altbn128Point2); ok {
 		return bytes.Equal(g2Point.point.Marshal(), other.point.Marshal())
@@ -200,6 +342,35 @@ func (g2Point *altbn128Point2) Equals(otherPoint2 Point) bool {
 	return false
 }
 
+// InCorrectSubgroup always returns true. The upstream library's on curve check,
+// which is run by MakeG2Point and UnmarshalG2, also checks that scaling the point
+// by the order of G2 gives the point at infinity, so every point is in G2.
+func (g2Point *altbn128Point2) InCorrectSubgroup() bool {
+	return true
+}
+
+// IsOnCurve checks that the point's affine coordinates satisfy the twist's
+// curve equation, y^2 = x^3 + 3/(i + 9). The point at infinity is on the curve.
+func (g2Point *altbn128Point2) IsOnCurve() bool {
+	return altbnG2IsOnCurve(g2Point.ToAffineCoords())
+}
+
+// altbnG2IsOnCurve expects coords to be of the form [x_im, x_re, y_im, y_re].
+func altbnG2IsOnCurve(coords []*big.Int) bool {
+	for i := 0; i < 4; i++ {
+		if coords[i].Cmp(altbnG1Q) >= 0 {
+			return false
+		}
+	}
+	if coords[0].Sign() == 0 && coords[1].Sign() == 0 && coords[2].Sign() == 0 && coords[3].Sign() == 0 {
+		return true
+	}
+	x := &complexNum{new(big.Int).Set(coords[0]), new(big.Int).Set(coords[1])}
+	y := &complexNum{new(big.Int).Set(coords[2]), new(big.Int).Set(coords[3])}
+	ySqr := getComplexZero().Square(y, altbnG1Q)
+	return ySqr.Equals(Altbn128.g2XToYSquared(x))
+}
+
 func (g2Point *altbn128Point2) Marshal() []byte {
 	coords := g2Point.ToAffineCoords()
 	xiBytes := pad32Bytes(coords[0].Bytes())
@@ -224,19 +395,20 @@ func (g2Point *altbn128Point2) MarshalUncompressed() []byte {
 	return g2Point.point.Marshal()
 }
 
-func (g2Point *altbn128Point2) Negate() *altbn128Point2 {
-	coords := g2Point.ToAffineCoords()
-	coords[2].Sub(altbnG1Q, coords[2])
-	coords[3].Sub(altbnG1Q, coords[3])
-	newPt, _ := Altbn128.MakeG2Point(coords, false)
-	return newPt.(*altbn128Point2)
+// Neg returns the additive inverse of the point.
+func (g2Point *altbn128Point2) Neg() Point {
+	return &altbn128Point2{new(bn256.G2).Neg(g2Point.point)}
+}
+
+func (g2Point *altbn128Point2) MulReduced(scalar *big.Int) Point {
+	return g2Point.Mul(new(big.Int).Mod(scalar, altbnG1Order))
 }
 
 func (g2Point *altbn128Point2) Mul(scalar *big.Int) Point {
 	scalar2 := new(big.Int)
 	cmp := scalar.Cmp(zero)
 	if cmp < 0 {
-		g2Point = g2Point.Negate()
+		g2Point = g2Point.Neg().(*altbn128Point2)
 		scalar2.Mul(scalar, big.NewInt(-1))
 	} else if cmp == 0 {
 		return Altbn128.GetG2Infinity()
@@ -287,6 +459,14 @@ func (gTPoint altbn128PointT) Equals(otherPointT PointT) bool {
 	return false
 }
 
+// Exp raises the element to the power scalar, which is reduced mod the group order.
+// The reduction is only valid in GT itself, so Exp may only be called on pairings
+// and finalized values, not on the raw output of MultiMillerLoop, which lies
+// outside of GT until FinalExponentiation is applied.
+func (gTPoint altbn128PointT) Exp(scalar *big.Int) PointT {
+	return gTPoint.Mul(new(big.Int).Mod(scalar, altbnG1Order))
+}
+
 func (gTPoint altbn128PointT) Mul(scalar *big.Int) PointT {
 	prod := new(bn256.GT).ScalarMult(gTPoint.point, scalar)
 	ret := altbn128PointT{prod}
@@ -327,6 +507,59 @@ func (curve *altbn128) UnmarshalG1(data []byte) (Point, bool) {
 	return nil, false
 }
 
+// MarshalCompressedEVM encodes an altbn128 G1 point in the 32 byte compressed
+// layout used by EVM verifiers: x as a big endian integer, with the most
+// significant bit set if y is odd. The identity is encoded as 32 zero bytes.
+// This fails if the point isn't in altbn128's G1.
+//
+// The layout is the one read by the Solidity verifier the encoding was written
+// for, which recovers y from x with the curve equation and picks the root by the
+// flag's parity; it isn't a published standard. In particular it differs from
+// the Zcash and gnark encodings, whose flag selects the lexicographically larger
+// root rather than the odd one.
+func MarshalCompressedEVM(pt Point) ([]byte, bool) {
+	if _, ok := pt.(*altbn128Point1); !ok {
+		return nil, false
+	}
+	coords := pt.ToAffineCoords()
+	xBytes := pad32Bytes(coords[0].Bytes())
+	if coords[1].Bit(0) == 1 {
+		xBytes[0] |= 0x80
+	}
+	return xBytes, true
+}
+
+// UnmarshalCompressedEVM decodes an altbn128 G1 point encoded by
+// MarshalCompressedEVM. y is recovered from the curve equation, and the root with
+// the encoded parity is chosen. Unlike UnmarshalG1, the input isn't modified.
+func UnmarshalCompressedEVM(data []byte) (Point, bool) {
+	if len(data) != 32 {
+		return nil, false
+	}
+	odd := data[0]&0x80 != 0
+	xBytes := append([]byte{}, data...)
+	xBytes[0] &= 0x7f
+	x := new(big.Int).SetBytes(xBytes)
+	if x.Sign() == 0 {
+		if odd {
+			return nil, false
+		}
+		return Altbn128.GetG1Infinity(), true
+	}
+	if x.Cmp(altbnG1Q) >= 0 {
+		return nil, false
+	}
+	ySquared := Altbn128.g1XToYSquared(x)
+	y := calcQuadRes(ySquared, altbnG1Q)
+	if new(big.Int).Exp(y, two, altbnG1Q).Cmp(ySquared) != 0 {
+		return nil, false
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(altbnG1Q, y)
+	}
+	return Altbn128.MakeG1Point([]*big.Int{x, y}, true)
+}
+
 func (curve *altbn128) UnmarshalG2(data []byte) (Point, bool) {
 	if data == nil || (len(data) != 64 && len(data) != 128) {
 		return nil, false
@@ -454,6 +687,22 @@ func (curve *altbn128) getFTHashParams() (*big.Int, *big.Int) {
 	return altbnSqrtn3, altbnZ
 }
 
+// MontgomeryParams returns Montgomery constants for F_q with R = 2^256, which is
+// the R that the upstream bn256 library uses for its Montgomery form elements.
+// inv is -q^{-1} mod 2^64, for word by word Montgomery reduction. Note that this
+// is not the constant upstream uses, as it reduces at full width with
+// -q^{-1} mod 2^256.
+func (curve *altbn128) MontgomeryParams() (r, rSquared, modulus *big.Int, inv uint64) {
+	r = new(big.Int).Lsh(one, 256)
+	r.Mod(r, altbnG1Q)
+	rSquared = new(big.Int).Mul(r, r)
+	rSquared.Mod(rSquared, altbnG1Q)
+	word := new(big.Int).Lsh(one, 64)
+	qInv := new(big.Int).ModInverse(altbnG1Q, word)
+	qInv.Sub(word, qInv)
+	return r, rSquared, new(big.Int).Set(altbnG1Q), qInv.Uint64()
+}
+
 //curve specific constants
 var altbnG1B = big.NewInt(3)
 var altbnG1Q, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
@@ -512,6 +761,27 @@ func (curve *altbn128) HashToG1(message []byte) Point {
 	return p
 }
 
+// HashToG1WithHash hashes a message to a point on Altbn128, with the supplied
+// hash function and domain separation tag. The message is hashed to two field
+// elements using expand_message_xmd from RFC 9380, each of which is mapped to
+// the curve with the Shallue - van de Woestijne encoding, and the results are
+// added. Any hash function with a fixed output size can be used, e.g.
+// sha256.New or sha3.NewKeccak256. Note that the mapping differs from the
+// RFC 9380 suites, so points won't match other implementations.
+func (curve *altbn128) HashToG1WithHash(message []byte, dst []byte, h func() hash.Hash) Point {
+	u, ok := hashToFieldG1(message, dst, 2, h, curve)
+	if !ok {
+		return nil
+	}
+	p0, ok0 := sw(curve, u[0], false)
+	p1, ok1 := sw(curve, u[1], false)
+	if !ok0 || !ok1 {
+		return nil
+	}
+	p, _ := p0.Add(p1)
+	return p
+}
+
 // EthereumSum256 returns the Keccak3-256 digest of the data. This is because Ethereum
 // uses a non-standard hashing algo.
 func EthereumSum256(data []byte) (digest [32]byte) {
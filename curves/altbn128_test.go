@@ -4,9 +4,14 @@
 package curves
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"math/big"
 	"testing"
 
+	gosha3 "github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,3 +42,170 @@ func TestEthereumHash(t *testing.T) {
 	altG2, _ := curve.MakeG2Point(coords, false)
 	assert.True(t, altG2.Equals(curve.GetG2()), "MakeG2Point Failed")
 }
+
+func TestMontgomeryParams(t *testing.T) {
+	for _, curve := range curves {
+		r, rSquared, q, inv := curve.MontgomeryParams()
+		assert.Zero(t, q.Cmp(curve.GetG1Q()), "modulus doesn't match the field modulus")
+		rInv := new(big.Int).ModInverse(r, q)
+		assert.NotNil(t, rInv, "R is not invertible mod q")
+		prod := new(big.Int).Mul(r, rInv)
+		assert.Zero(t, prod.Mod(prod, q).Cmp(one), "R * R^{-1} mod q != 1")
+		sqr := new(big.Int).Mul(r, r)
+		assert.Zero(t, sqr.Mod(sqr, q).Cmp(rSquared), "R^2 mod q is incorrect")
+		// inv * q should be -1 mod 2^64
+		assert.Equal(t, ^uint64(0), inv*q.Uint64(), "inv is not -q^{-1} mod 2^64")
+	}
+}
+
+func TestHashToG1WithHash(t *testing.T) {
+	for _, curve := range curves {
+		dst := []byte("BGLS_TEST_DST")
+		for i := 0; i < 10; i++ {
+			msg := make([]byte, 64)
+			rand.Read(msg)
+			shaPt := curve.HashToG1WithHash(msg, dst, sha256.New)
+			keccakPt := curve.HashToG1WithHash(msg, dst, gosha3.NewKeccak256)
+			sha512Pt := curve.HashToG1WithHash(msg, dst, sha512.New)
+			assert.NotNil(t, shaPt)
+			assert.NotNil(t, keccakPt)
+			assert.NotNil(t, sha512Pt)
+			assert.False(t, shaPt.Equals(keccakPt), "SHA256 and Keccak256 hash to the same point")
+			assert.False(t, shaPt.Equals(sha512Pt), "SHA256 and SHA512 hash to the same point")
+			assert.True(t, keccakPt.Equals(curve.HashToG1WithHash(msg, dst, gosha3.NewKeccak256)),
+				"Hashing is not deterministic")
+			assert.False(t, keccakPt.Equals(curve.HashToG1WithHash(msg, []byte("OTHER_DST"), gosha3.NewKeccak256)),
+				"Differing domain separation tags hash to the same point")
+		}
+	}
+}
+
+func TestHashToG1WithHashDomainSeparation(t *testing.T) {
+	for _, curve := range curves {
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		pt1 := curve.HashToG1WithHash(append([]byte{0}, msg...), []byte("A"), sha256.New)
+		pt2 := curve.HashToG1WithHash(msg, []byte("A\x00"), sha256.New)
+		assert.False(t, pt1.Equals(pt2), "Moving a byte from the message to the tag doesn't change the point")
+	}
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	// Test vectors from RFC 9380, appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	tests := []struct {
+		msg      string
+		expected string
+	}{
+		{"", "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	}
+	for _, test := range tests {
+		out, ok := expandMessageXMD([]byte(test.msg), dst, 32, sha256.New)
+		assert.True(t, ok)
+		assert.Equal(t, test.expected, hex.EncodeToString(out), "expand_message_xmd doesn't match test vector")
+	}
+	long, ok := expandMessageXMD([]byte("abc"), dst, 0x80, sha256.New)
+	assert.True(t, ok)
+	assert.Equal(t, 0x80, len(long))
+	_, ok = expandMessageXMD([]byte("abc"), dst, 256*32, sha256.New)
+	assert.False(t, ok, "Expanded past the maximum output length")
+}
+
+func TestIsOnCurve(t *testing.T) {
+	curve := Altbn128
+	for i := 0; i < 5; i++ {
+		scalar, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		g1 := curve.GetG1().Mul(scalar)
+		g2 := curve.GetG2().Mul(scalar)
+		assert.True(t, g1.IsOnCurve(), "G1 point isn't on the curve")
+		assert.True(t, g2.IsOnCurve(), "G2 point isn't on the curve")
+
+		coords := g1.ToAffineCoords()
+		coords[1].Add(coords[1], one)
+		assert.False(t, altbnG1IsOnCurve(coords[0], coords[1]), "G1 point with a tampered coordinate is on the curve")
+		coords[1].Sub(coords[1], one).Add(coords[1], altbnG1Q)
+		assert.False(t, altbnG1IsOnCurve(coords[0], coords[1]), "G1 point with an unreduced coordinate is on the curve")
+
+		coords = g2.ToAffineCoords()
+		coords[0].Add(coords[0], one)
+		assert.False(t, altbnG2IsOnCurve(coords), "G2 point with a tampered coordinate is on the curve")
+	}
+	assert.True(t, curve.GetG1Infinity().IsOnCurve())
+	assert.True(t, curve.GetG2Infinity().IsOnCurve())
+}
+
+func TestInCorrectSubgroup(t *testing.T) {
+	curve := Altbn128
+	for i := 0; i < 5; i++ {
+		scalar, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		assert.True(t, curve.GetG1().Mul(scalar).InCorrectSubgroup())
+		assert.True(t, curve.GetG2().Mul(scalar).InCorrectSubgroup())
+	}
+	assert.True(t, curve.GetG1Infinity().InCorrectSubgroup())
+	assert.True(t, curve.GetG2Infinity().InCorrectSubgroup())
+}
+
+func TestCompressedEVM(t *testing.T) {
+	// The encodings of G, -G and 2G, where G = (1, 2)
+	fixtures := []struct {
+		scalar  int64
+		encoded string
+	}{
+		{1, "0000000000000000000000000000000000000000000000000000000000000001"},
+		{-1, "8000000000000000000000000000000000000000000000000000000000000001"},
+		{2, "030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3"},
+		{0, "0000000000000000000000000000000000000000000000000000000000000000"},
+	}
+	for _, fixture := range fixtures {
+		pt := Altbn128.GetG1().Mul(big.NewInt(fixture.scalar))
+		encoded, ok := MarshalCompressedEVM(pt)
+		assert.True(t, ok)
+		assert.Equal(t, fixture.encoded, hex.EncodeToString(encoded))
+		data, _ := hex.DecodeString(fixture.encoded)
+		decoded, ok := UnmarshalCompressedEVM(data)
+		assert.True(t, ok && decoded.Equals(pt), "Decoding fixture %s failed", fixture.encoded)
+		assert.Equal(t, fixture.encoded, hex.EncodeToString(data), "Decoding modified the input")
+	}
+	// The point output by the Solidity hash to curve test case in
+	// TestEthereumHash, whose y is odd, in the layout built by hand from its
+	// coordinates rather than by MarshalCompressedEVM.
+	solX, _ := new(big.Int).SetString("11423386531623885114587219621463106117140760157404497425836076043015227528156", 10)
+	solY, _ := new(big.Int).SetString("20262289731964024720969923714809935701428881933342918937283877214228227624643", 10)
+	solPt, ok := Altbn128.MakeG1Point([]*big.Int{solX, solY}, true)
+	assert.True(t, ok)
+	solEncoded := "9941685bd908df86cb2ed8b9788a32e099bd94dcf1bcfd6ab15efdf2597953dc"
+	data, _ := hex.DecodeString(solEncoded)
+	decoded, ok := UnmarshalCompressedEVM(data)
+	assert.True(t, ok && decoded.Equals(solPt), "Decoding the Solidity point failed")
+	encoded, _ := MarshalCompressedEVM(solPt)
+	assert.Equal(t, solEncoded, hex.EncodeToString(encoded))
+
+	for i := 0; i < 20; i++ {
+		k, _ := rand.Int(rand.Reader, Altbn128.GetG1Order())
+		pt := Altbn128.GetG1().Mul(k)
+		encoded, _ := MarshalCompressedEVM(pt)
+		decoded, ok := UnmarshalCompressedEVM(encoded)
+		assert.True(t, ok && decoded.Equals(pt), "EVM compressed round trip failed")
+	}
+	_, ok = MarshalCompressedEVM(Altbn128.GetG2())
+	assert.False(t, ok, "G2 point encoded in the G1 layout")
+	// x = 4 has no point on the curve, since 4^3 + 3 isn't a square
+	data, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000004")
+	_, ok = UnmarshalCompressedEVM(data)
+	assert.False(t, ok, "x without a point on the curve decoded")
+	_, ok = UnmarshalCompressedEVM(pad32Bytes(altbnG1Q.Bytes()))
+	assert.False(t, ok, "x outside the field decoded")
+	_, ok = UnmarshalCompressedEVM(data[1:])
+	assert.False(t, ok, "Short encoding decoded")
+}
+
+func TestAltbn128Parameters(t *testing.T) {
+	params := Altbn128.Parameters()
+	assert.Equal(t, "altbn128", params.Name)
+	assert.Equal(t, 12, params.EmbeddingDegree)
+	assert.Equal(t, len(Altbn128.GetG1().Marshal()), params.G1Size)
+	assert.Equal(t, len(Altbn128.GetG2().Marshal()), params.G2Size)
+	assert.Equal(t, 254, params.ScalarBits)
+	assert.Equal(t, 100, params.SecurityBits)
+}
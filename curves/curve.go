@@ -4,7 +4,14 @@
 package curves
 
 import (
+	"crypto/rand"
+	"errors"
+	"hash"
 	"math/big"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
 )
 
 // CurveSystem is a set of parameters and functions for a pairing based cryptosystem
@@ -30,12 +37,18 @@ type CurveSystem interface {
 	GetGTIdentity() PointT
 
 	HashToG1(message []byte) Point
+	// HashToG1WithHash hashes a message to G1, using the supplied hash function
+	// and domain separation tag.
+	HashToG1WithHash(message []byte, dst []byte, h func() hash.Hash) Point
 
 	GetG1Q() *big.Int
 	GetG1Order() *big.Int
 	// getGTQ() *big.Int
 
 	getG1Cofactor() *big.Int
+	// MontgomeryParams returns R, R^2 mod q, q and -q^{-1} mod 2^64, the constants
+	// underlying the curve's Montgomery form field arithmetic in F_q.
+	MontgomeryParams() (r, rSquared, modulus *big.Int, inv uint64)
 
 	getG1A() *big.Int
 	getG1B() *big.Int
@@ -46,178 +59,512 @@ type CurveSystem interface {
 	Pair(Point, Point) (PointT, bool)
 	// Product of Pairings
 	PairingProduct([]Point, []Point) (PointT, bool)
+	// MultiMillerLoop computes the product of the Miller loops of each pair,
+	// without the final exponentiation. The result is only an element of GT,
+	// comparable with pairings, after FinalExponentiation.
+	MultiMillerLoop([]PairInput) (PointT, bool)
+	// FinalExponentiation maps the output of MultiMillerLoop into GT.
+	FinalExponentiation(PointT) PointT
+
+	// Parameters describes the curve, e.g. for reporting the cryptography in use.
+	Parameters() CurveParameters
+}
+
+// CurveParameters describes a curve system's sizes and security.
+type CurveParameters struct {
+	Name string
+	// EmbeddingDegree is the degree of the extension field GT lies in.
+	EmbeddingDegree int
+	// G1Size and G2Size are the lengths in bytes of compressed points.
+	G1Size int
+	G2Size int
+	// ScalarBits is the bit length of the order of the groups.
+	ScalarBits int
+	// SecurityBits is an approximate security level, in bits.
+	SecurityBits int
+}
+
+// PairInput is a pair of points to be paired, with G1 in G1 and G2 in G2.
+type PairInput struct {
+	G1 Point
+	G2 Point
 }
 
 // Point is a way to represent a point on G1 or G2, in the first two elliptic curves.
 type Point interface {
 	Add(Point) (Point, bool)
+	// Cmp compares the compressed encodings of two points lexicographically,
+	// returning -1, 0, or 1. This gives a total order for sorting points.
+	Cmp(Point) int
 	Copy() Point
+	// Curve returns the curve system which the point belongs to.
+	Curve() CurveSystem
 	Equals(Point) bool
+	// InCorrectSubgroup checks that the point is in the prime order subgroup
+	// used for G1 or G2.
+	InCorrectSubgroup() bool
+	// IsOnCurve checks that the point's affine coordinates satisfy the curve
+	// equation. This is much cheaper than checking the subgroup.
+	IsOnCurve() bool
 	Marshal() []byte
 	MarshalUncompressed() []byte
 	Mul(*big.Int) Point
+	// MulReduced reduces the scalar modulo the group order before multiplying,
+	// so scalars of any size or sign cost one multiplication by a scalar below
+	// the order.
+	MulReduced(*big.Int) Point
+	Neg() Point
 	ToAffineCoords() []*big.Int
 }
 
-// PointT is a way to represent a point on GT, in the target group
+// PointT is a way to represent a point on GT, in the target group.
+// Like Point, PointT is written additively, so Add is the group operation of GT,
+// i.e. multiplication of target group elements, and Mul scales by an integer.
 type PointT interface {
 	Add(PointT) (PointT, bool)
 	Copy() PointT
 	Equals(PointT) bool
+	// Exp raises the element to the power scalar, in the multiplicative notation
+	// for GT. Negative scalars give powers of the inverse. Exp is only defined
+	// on elements of GT, so an unfinalized MultiMillerLoop output must go
+	// through FinalExponentiation first.
+	Exp(*big.Int) PointT
 	Marshal() []byte
 	Mul(*big.Int) PointT
 	// ToAffineCoords() (*big.Int, *big.Int)
 }
 
-// AggregatePoints takes the sum of points.
+// CheckPairingEquals checks that e(a, b) equals target, where a is in G1 and
+// b is in G2.
+func CheckPairingEquals(curve CurveSystem, a Point, b Point, target PointT) bool {
+	return CheckPairingProduct(curve, []Point{a}, []Point{b}, target)
+}
+
+// CheckPairingProduct checks that the product of the pairings e(as[i], bs[i])
+// equals target, where each as[i] is in G1 and each bs[i] is in G2.
+func CheckPairingProduct(curve CurveSystem, as []Point, bs []Point, target PointT) bool {
+	if target == nil || len(as) == 0 || len(as) != len(bs) {
+		return false
+	}
+	paired, ok := curve.PairingProduct(as, bs)
+	if !ok {
+		return false
+	}
+	return paired.Equals(target)
+}
+
+// defaultAggregationThreshold is the largest number of points which
+// AggregatePoints sums sequentially, for groups which haven't been tuned.
+const defaultAggregationThreshold = 2
+
+// aggregationThresholds maps the type of a group's points to the largest number
+// of those points which AggregatePoints sums sequentially. Larger sets of points
+// are summed concurrently.
+var aggregationThresholds sync.Map
+
+// AggregationThreshold returns the largest number of points of the same group
+// as pt which AggregatePoints sums without concurrency. See AutoTuneAggregation.
+func AggregationThreshold(pt Point) int {
+	if threshold, ok := aggregationThresholds.Load(reflect.TypeOf(pt)); ok {
+		return threshold.(int)
+	}
+	return defaultAggregationThreshold
+}
+
+// AutoTuneAggregation measures sequential and concurrent aggregation of
+// multiples of generator at a few sizes on this machine, and sets the threshold
+// below which AggregatePoints aggregates points of generator's group
+// sequentially to the largest size at which the sequential method was faster.
+// Each method is timed over several rounds, and the fastest round is used.
+// Since additions in G1 and G2 differ in cost, each group should be tuned
+// separately. It takes a few milliseconds, and is meant to be called once at
+// startup. It returns the chosen threshold.
+func AutoTuneAggregation(generator Point) int {
+	const rounds = 8
+	sizes := []int{4, 8, 16, 32, 64}
+	pts := make([]Point, sizes[len(sizes)-1])
+	pts[0] = generator
+	for i := 1; i < len(pts); i++ {
+		pts[i], _ = pts[i-1].Add(generator)
+	}
+	fastest := func(aggregate func([]Point) (Point, error), points []Point) time.Duration {
+		var best time.Duration
+		for i := 0; i < rounds; i++ {
+			start := time.Now()
+			aggregate(points)
+			if elapsed := time.Since(start); i == 0 || elapsed < best {
+				best = elapsed
+			}
+		}
+		return best
+	}
+	threshold := defaultAggregationThreshold
+	for _, size := range sizes {
+		sequential := fastest(aggregatePointsSequentially, pts[:size])
+		concurrent := fastest(aggregatePointsConcurrently, pts[:size])
+		if sequential > concurrent {
+			break
+		}
+		threshold = size
+	}
+	aggregationThresholds.Store(reflect.TypeOf(generator), threshold)
+	return threshold
+}
+
+// ErrCurveMismatch is returned when a point doesn't belong to the expected curve.
+var ErrCurveMismatch = errors.New("point doesn't belong to the curve")
+
+// CheckCurve checks that all of the points belong to curve, by comparing the
+// curves' names. It returns ErrCurveMismatch if any point belongs to another
+// curve, or is nil.
+func CheckCurve(curve CurveSystem, points ...Point) error {
+	for _, pt := range points {
+		if pt == nil || pt.Curve().Name() != curve.Name() {
+			return ErrCurveMismatch
+		}
+	}
+	return nil
+}
+
+// standardGenerators holds the spec defined affine coordinates of the G1 and G2
+// generators of known curves, by curve name, in the order MakeG1Point and
+// MakeG2Point take them. The altbn128 generators are those of EIP-197.
+var standardGenerators = map[string][2][]string{
+	"altbn128": {
+		{"1", "2"},
+		{"11559732032986387107991004021392285783925812861821192530917403151452391805634",
+			"10857046999023057135944570762232829481370756359578518086990519993285655852781",
+			"4082367875863433681332203403145435568316851327593401208105741076214120093531",
+			"8495653923123431417604973247489272438418190587263600148770280649306958101930"},
+	},
+}
+
+// VerifyGenerators is a sanity check of a curve's generators, e.g. at startup to
+// catch a misconfigured custom curve. It checks that the G1 and G2 generators are
+// on the curve, aren't the identity, are in the correct subgroup and pair to a
+// non identity element. For known curves, it also checks that they are the
+// standard generators.
+func VerifyGenerators(curve CurveSystem) bool {
+	g1, g2 := curve.GetG1(), curve.GetG2()
+	if g1 == nil || g2 == nil {
+		return false
+	}
+	if g1.Equals(curve.GetG1Infinity()) || g2.Equals(curve.GetG2Infinity()) {
+		return false
+	}
+	if !g1.IsOnCurve() || !g2.IsOnCurve() || !g1.InCorrectSubgroup() || !g2.InCorrectSubgroup() {
+		return false
+	}
+	gt, ok := curve.Pair(g1, g2)
+	if !ok || gt.Equals(curve.GetGTIdentity()) {
+		return false
+	}
+	if standard, known := standardGenerators[curve.Name()]; known {
+		expected1, ok1 := curve.MakeG1Point(parseCoords(standard[0]), true)
+		expected2, ok2 := curve.MakeG2Point(parseCoords(standard[1]), true)
+		if !ok1 || !ok2 || !g1.Equals(expected1) || !g2.Equals(expected2) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCoords parses base 10 coordinates.
+func parseCoords(coords []string) []*big.Int {
+	parsed := make([]*big.Int, len(coords))
+	for i, coord := range coords {
+		parsed[i], _ = new(big.Int).SetString(coord, 10)
+	}
+	return parsed
+}
+
+// ErrAggregationFailed is returned by SafeAggregatePoints when adding two of the
+// points failed, e.g. as they are in different groups, or panicked, e.g. due to
+// a nil point.
+var ErrAggregationFailed = errors.New("adding points failed during aggregation")
+
+// AggregatePoints takes the sum of points. It returns nil if there are no points,
+// or if adding two of the points failed. See SafeAggregatePoints.
+// A single point is copied, without any concurrency.
 func AggregatePoints(points []Point) Point {
-	if len(points) == 2 { // No parallelization needed
-		aggPoint, _ := points[0].Add(points[1])
-		return aggPoint
+	aggPoint, _ := SafeAggregatePoints(points)
+	return aggPoint
+}
+
+// SafeAggregatePoints takes the sum of points. It returns ErrCurveMismatch if
+// the points don't all belong to the same curve. If adding any two of the points
+// fails or panics, the aggregation is aborted and ErrAggregationFailed is returned,
+// without leaking any of the goroutines used for concurrent aggregation.
+func SafeAggregatePoints(points []Point) (Point, error) {
+	if len(points) == 1 {
+		if points[0] == nil {
+			return nil, ErrAggregationFailed
+		}
+		return points[0].Copy(), nil
+	}
+	if len(points) != 0 && points[0] != nil {
+		name := points[0].Curve().Name()
+		for _, pt := range points[1:] {
+			// nil points are left to fail during aggregation
+			if pt != nil && pt.Curve().Name() != name {
+				return nil, ErrCurveMismatch
+			}
+		}
+	}
+	if len(points) == 0 || len(points) <= AggregationThreshold(points[0]) { // No parallelization needed
+		return aggregatePointsSequentially(points)
+	}
+	return aggregatePointsConcurrently(points)
+}
+
+// ErrDuplicatePoint is returned by AggregatePointsUnique when a point appears
+// more than once.
+var ErrDuplicatePoint = errors.New("point appears more than once")
+
+// AggregatePointsUnique takes the sum of points, as SafeAggregatePoints does,
+// after checking that no point appears twice, by comparing their compressed
+// encodings. This catches e.g. a key counted twice in a committee's aggregate
+// public key. It returns the sum and the number of points summed, or
+// ErrDuplicatePoint if any point is repeated, or ErrAggregationFailed for a nil
+// point.
+func AggregatePointsUnique(points []Point) (Point, int, error) {
+	seen := make(map[string]bool, len(points))
+	for _, pt := range points {
+		if pt == nil {
+			return nil, 0, ErrAggregationFailed
+		}
+		encoded := string(pt.Marshal())
+		if seen[encoded] {
+			return nil, 0, ErrDuplicatePoint
+		}
+		seen[encoded] = true
 	}
-	// Aggregate all the points together using concurrency
-	c := make(chan Point)
+	sum, err := SafeAggregatePoints(points)
+	if err != nil {
+		return nil, 0, err
+	}
+	return sum, len(points), nil
+}
 
-	// Initialize aggPoint to an array with elements being the sum of two
-	// adjacent Points.
-	counter := 0
-	for i := 0; i < len(points); i += 2 {
-		go concurrentAggregatePoints(i, points, c)
-		counter++
+// aggregatePointsSequentially takes the sum of points without concurrency.
+func aggregatePointsSequentially(points []Point) (aggPoint Point, err error) {
+	if len(points) == 0 {
+		return nil, nil
 	}
-	aggPoint := make([]Point, counter)
-	for i := 0; i < counter; i++ {
-		aggPoint[i] = <-c
+	defer func() {
+		if recover() != nil {
+			aggPoint, err = nil, ErrAggregationFailed
+		}
+	}()
+	aggPoint = points[0]
+	for i := 1; i < len(points); i++ {
+		var ok bool
+		if aggPoint, ok = aggPoint.Add(points[i]); !ok {
+			return nil, ErrAggregationFailed
+		}
 	}
+	return aggPoint, nil
+}
 
+// aggregatePointsConcurrently takes the sum of points, adding pairs of points
+// concurrently. Each round's channel is buffered, so that if the aggregation is
+// aborted the remaining goroutines can still send their results and exit.
+func aggregatePointsConcurrently(points []Point) (Point, error) {
 	// Keep on aggregating every pair of points until only one aggregate point remains
-	for {
-		counter = 0
-		if len(aggPoint) == 1 {
-			break
-		}
+	aggPoint := points
+	for len(aggPoint) > 1 {
+		counter := (len(aggPoint) + 1) / 2
+		c := make(chan Point, counter)
 		for i := 0; i < len(aggPoint); i += 2 {
 			go concurrentAggregatePoints(i, aggPoint, c)
-			counter++
 		}
 		nxtAggPoint := make([]Point, counter)
 		for i := 0; i < counter; i++ {
-			nxtAggPoint[i] = <-c
+			// A nil point is sent if adding the points failed.
+			if nxtAggPoint[i] = <-c; nxtAggPoint[i] == nil {
+				return nil, ErrAggregationFailed
+			}
 		}
 		aggPoint = nxtAggPoint
 	}
-	return aggPoint[0]
+	return aggPoint[0], nil
 }
 
 // concurrentAggregatePoints handles the channel for concurrent Aggregation of points.
-// It only adds the element at points[start] and points[start + 1], and sends it through the channel
+// It only adds the element at points[start] and points[start + 1], and sends it through the channel.
+// If adding the points fails or panics, nil is sent instead.
 func concurrentAggregatePoints(start int, points []Point, c chan Point) {
+	defer func() {
+		if recover() != nil {
+			c <- nil
+		}
+	}()
 	if start+1 >= len(points) {
 		c <- points[start]
 		return
 	}
-	summed, _ := points[start].Add(points[start+1])
+	summed, ok := points[start].Add(points[start+1])
+	if !ok {
+		summed = nil
+	}
 	c <- summed
 }
 
-// concurrentPairingProduct computes a set of pairings in parallel,
-// and then takes their product again using concurrency.
-func concurrentPairingProduct(curve CurveSystem, points1 []Point, points2 []Point) (PointT, bool) {
-	if len(points1) != len(points2) {
-		return nil, false
+// HashToG1Batch hashes each of the messages to G1 with curve.HashToG1, spread
+// across a pool of workers. The result's i-th point is the hash of msgs[i].
+func HashToG1Batch(curve CurveSystem, msgs [][]byte) []Point {
+	return HashToG1BatchCustHash(msgs, curve.HashToG1)
+}
+
+// HashToG1BatchCustHash hashes each of the messages to G1 as HashToG1Batch does,
+// using the supplied hash function.
+func HashToG1BatchCustHash(msgs [][]byte, hash func([]byte) Point) []Point {
+	pts := make([]Point, len(msgs))
+	workers := runtime.NumCPU()
+	if workers > len(msgs) {
+		workers = len(msgs)
 	}
-	// Compute all the pairings in parallel
-	c := make(chan PointT)
-	pairedPoints := make([]PointT, len(points1))
-	for i := 0; i < len(pairedPoints); i++ {
-		go concurrentPair(curve, points1[i], points2[i], c)
+	indices := make(chan int, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		indices <- i
 	}
-	for i := 0; i < len(pairedPoints); i++ {
-		pairedPoints[i] = <-c
-		if pairedPoints[i] == nil {
-			return nil, false
-		}
+	close(indices)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go concurrentHashToG1(hash, msgs, pts, indices, &wg)
 	}
-	counter := 0
-	// Set aggPairedPoints to an array with elements being the sum of two
-	// adjacent Points.
-	for i := 0; i < len(pairedPoints); i += 2 {
-		go concurrentAggregatePointTs(i, pairedPoints, c)
-		counter++
+	wg.Wait()
+	return pts
+}
+
+// BatchSubgroupCheck checks whether each of the points is in its correct
+// subgroup across a pool of workers, e.g. to validate a block's keys and
+// signatures in one pass. valid[i] is false if points[i] is nil or outside of its
+// subgroup.
+func BatchSubgroupCheck(points []Point) []bool {
+	valid := make([]bool, len(points))
+	workers := runtime.NumCPU()
+	if workers > len(points) {
+		workers = len(points)
+	}
+	indices := make(chan int, len(points))
+	for i := 0; i < len(points); i++ {
+		indices <- i
 	}
-	aggPairedPoints := make([]PointT, counter)
-	for i := 0; i < counter; i++ {
-		aggPairedPoints[i] = <-c
+	close(indices)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go concurrentSubgroupCheck(points, valid, indices, &wg)
 	}
+	wg.Wait()
+	return valid
+}
 
-	// Keep on aggregating every pair of points until only one aggregate point remains
-	for {
-		counter = 0
-		if len(aggPairedPoints) == 1 {
-			break
-		}
-		for i := 0; i < len(aggPairedPoints); i += 2 {
-			go concurrentAggregatePointTs(i, aggPairedPoints, c)
-			counter++
-		}
-		nxtPairedPoints := make([]PointT, counter)
-		for i := 0; i < counter; i++ {
-			nxtPairedPoints[i] = <-c
-		}
-		aggPairedPoints = nxtPairedPoints
+// concurrentSubgroupCheck checks the points whose indices are received on the channel.
+func concurrentSubgroupCheck(points []Point, valid []bool, indices chan int, wg *sync.WaitGroup) {
+	for i := range indices {
+		valid[i] = points[i] != nil && points[i].InCorrectSubgroup()
 	}
-	return aggPairedPoints[0], true
+	wg.Done()
 }
 
-// concurrentAggregatePoints handles the channel for concurrent Aggregation of points.
-// It only adds the element at points[start] and points[start + 1], and sends it through the channel
-func concurrentAggregatePointTs(start int, points []PointT, c chan PointT) {
-	if start+1 >= len(points) {
-		c <- points[start]
-		return
+// concurrentHashToG1 hashes the messages whose indices are received on the channel.
+func concurrentHashToG1(hash func([]byte) Point, msgs [][]byte, pts []Point, indices chan int, wg *sync.WaitGroup) {
+	for i := range indices {
+		pts[i] = hash(msgs[i])
 	}
-	summed, _ := points[start].Add(points[start+1])
-	c <- summed
+	wg.Done()
 }
 
-type indexedPoint struct {
-	index int
-	pt    Point
+// HashSetToG1 hashes a set of messages to a single point in G1, which is the sum
+// of each message's hash with curve.HashToG1. The result doesn't depend on the
+// order of the messages, and can be updated with AddToSetHash as messages are
+// added. Since it's a sum, a message included twice is counted twice. The empty
+// set hashes to the identity.
+func HashSetToG1(curve CurveSystem, msgs [][]byte) Point {
+	sum := curve.GetG1Infinity()
+	for _, pt := range HashToG1Batch(curve, msgs) {
+		sum, _ = sum.Add(pt)
+	}
+	return sum
+}
+
+// AddToSetHash adds msg to a set hash created by HashSetToG1.
+func AddToSetHash(curve CurveSystem, current Point, msg []byte) Point {
+	sum, _ := current.Add(curve.HashToG1(msg))
+	return sum
+}
+
+// RandomG1 returns a uniformly random point of G1 other than the identity, as the
+// G1 generator times a random scalar. It is meant for tests and benchmarks.
+func RandomG1(curve CurveSystem) Point {
+	return curve.GetG1().Mul(randomScalar(curve))
+}
+
+// RandomG2 returns a uniformly random point of G2 other than the identity, as
+// RandomG1 does for G1.
+func RandomG2(curve CurveSystem) Point {
+	return curve.GetG2().Mul(randomScalar(curve))
+}
+
+// randomScalar returns a uniformly random scalar in [1, order-1]. It panics if
+// crypto/rand fails, as RandomG1 and RandomG2 have no error to return.
+func randomScalar(curve CurveSystem) *big.Int {
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(curve.GetG1Order(), one))
+	if err != nil {
+		panic("curves: reading random scalar failed: " + err.Error())
+	}
+	return k.Add(k, one)
 }
 
 // ScalePoints takes a set of points, and a set of multiples, and returns a
-// new set of points multiplied by the corresponding factor.
+// new set of points multiplied by the corresponding factor. The scaling is
+// spread across runtime.NumCPU() workers, as in ScalePointsParallel.
 func ScalePoints(pts []Point, factors []*big.Int) (newKeys []Point) {
+	return ScalePointsParallel(pts, factors, runtime.NumCPU())
+}
+
+// ScalePointsParallel scales the points as ScalePoints does, with at most workers
+// goroutines, where newKeys[i] is pts[i] times factors[i]. A nil factor leaves the
+// point unscaled. If factors is nil, pts is returned as is, and if pts and
+// factors differ in length, nil is returned.
+func ScalePointsParallel(pts []Point, factors []*big.Int, workers int) (newKeys []Point) {
 	if factors == nil {
 		return pts
 	} else if len(pts) != len(factors) {
 		return nil
 	}
 	newKeys = make([]Point, len(pts))
-	c := make(chan *indexedPoint)
-	for i := 0; i < len(pts); i++ {
-		go concurrentScale(pts[i], factors[i], i, c)
+	if workers > len(pts) {
+		workers = len(pts)
+	}
+	if workers < 1 {
+		workers = 1
 	}
+	indices := make(chan int, len(pts))
 	for i := 0; i < len(pts); i++ {
-		pt := <-c
-		newKeys[pt.index] = pt.pt
+		indices <- i
 	}
-	return newKeys
-}
-
-func concurrentScale(key Point, factor *big.Int, index int, c chan *indexedPoint) {
-	if factor == nil {
-		c <- &indexedPoint{index, key.Copy()}
-	} else {
-		c <- &indexedPoint{index, key.Mul(factor)}
+	close(indices)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go concurrentScale(pts, factors, newKeys, indices, &wg)
 	}
+	wg.Wait()
+	return newKeys
 }
 
-// concurrentPair pairs pt with key, and sends the result down the channel.
-func concurrentPair(curve CurveSystem, pt1 Point, pt2 Point, c chan PointT) {
-	if targetPoint, ok := curve.Pair(pt1, pt2); ok {
-		c <- targetPoint
-		return
+// concurrentScale scales the points whose indices are received on the channel.
+func concurrentScale(pts []Point, factors []*big.Int, newKeys []Point, indices chan int, wg *sync.WaitGroup) {
+	for i := range indices {
+		if factors[i] == nil {
+			newKeys[i] = pts[i].Copy()
+		} else {
+			newKeys[i] = pts[i].Mul(factors[i])
+		}
 	}
-	c <- nil
+	wg.Done()
 }
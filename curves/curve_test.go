@@ -9,9 +9,14 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"reflect"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	b64 "encoding/base64"
 
@@ -140,6 +145,51 @@ func TestMul(t *testing.T) {
 	}
 }
 
+func TestNeg(t *testing.T) {
+	for _, curve := range curves {
+		for i := 0; i < 8; i++ {
+			scalar, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			scalarNeg := new(big.Int).Sub(zero, scalar)
+			for _, g := range []Point{curve.GetG1(), curve.GetG2()} {
+				pt := g.Mul(scalar)
+				neg := pt.Neg()
+				assert.True(t, neg.Equals(g.Mul(scalarNeg)), "Neg is inconsistent with Mul by -1")
+				sum, _ := pt.Add(neg)
+				assert.True(t, sum.Equals(g.Mul(zero)), "P + (-P) is not the identity")
+				assert.True(t, neg.Neg().Equals(pt), "-(-P) != P")
+			}
+		}
+		assert.True(t, curve.GetG1Infinity().Neg().Equals(curve.GetG1Infinity()))
+		assert.True(t, curve.GetG2Infinity().Neg().Equals(curve.GetG2Infinity()))
+	}
+}
+
+func TestCmp(t *testing.T) {
+	for _, curve := range curves {
+		for _, g := range []Point{curve.GetG1(), curve.GetG2()} {
+			pts := make([]Point, 10)
+			for i := 0; i < len(pts); i++ {
+				x, _ := rand.Int(rand.Reader, curve.GetG1Order())
+				pts[i] = g.Mul(x)
+			}
+			reversed := make([]Point, len(pts))
+			for i := 0; i < len(pts); i++ {
+				reversed[i] = pts[len(pts)-1-i]
+			}
+			sort.Slice(pts, func(i, j int) bool { return pts[i].Cmp(pts[j]) < 0 })
+			sort.Slice(reversed, func(i, j int) bool { return reversed[i].Cmp(reversed[j]) < 0 })
+			for i := 0; i < len(pts); i++ {
+				assert.True(t, pts[i].Equals(reversed[i]), "Sorting points isn't deterministic")
+				assert.Equal(t, 0, pts[i].Cmp(pts[i].Copy()))
+				if i > 0 {
+					assert.Equal(t, -1, pts[i-1].Cmp(pts[i]), "Points aren't sorted")
+					assert.Equal(t, 1, pts[i].Cmp(pts[i-1]), "Cmp isn't antisymmetric")
+				}
+			}
+		}
+	}
+}
+
 func TestPairingProd(t *testing.T) {
 	// TODO: Make upstream libraries include proper product of pairing functionality
 	for _, curve := range curves {
@@ -164,6 +214,89 @@ func TestPairingProd(t *testing.T) {
 	}
 }
 
+func TestGTExp(t *testing.T) {
+	for _, curve := range curves {
+		a, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		b, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		base, _ := curve.Pair(curve.GetG1(), curve.GetG2())
+		paired, _ := curve.Pair(curve.GetG1().Mul(a), curve.GetG2().Mul(b))
+		ab := new(big.Int).Mul(a, b)
+		assert.True(t, paired.Equals(base.Exp(ab)), "e(aP, bQ) != e(P, Q)^ab")
+
+		// Add is the group operation, so e(P, Q)^a * e(P, Q)^b = e(P, Q)^(a+b)
+		prod, _ := base.Exp(a).Add(base.Exp(b))
+		assert.True(t, prod.Equals(base.Exp(new(big.Int).Add(a, b))))
+		inv, _ := base.Exp(a).Add(base.Exp(new(big.Int).Neg(a)))
+		assert.True(t, inv.Equals(curve.GetGTIdentity()), "e(P, Q)^a * e(P, Q)^-a isn't the identity")
+	}
+}
+
+func TestMultiMillerLoop(t *testing.T) {
+	for _, curve := range curves {
+		N := 5
+		pairs := make([]PairInput, N)
+		product := curve.GetGTIdentity()
+		for i := 0; i < N; i++ {
+			a, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			b, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			pairs[i] = PairInput{curve.GetG1().Mul(a), curve.GetG2().Mul(b)}
+			paired, _ := curve.Pair(pairs[i].G1, pairs[i].G2)
+			product, _ = product.Add(paired)
+		}
+		miller, ok := curve.MultiMillerLoop(pairs)
+		assert.True(t, ok)
+		assert.True(t, curve.FinalExponentiation(miller).Equals(product),
+			"Final exponentiation of the Miller loops differs from the product of pairings")
+		single, _ := curve.MultiMillerLoop(pairs[:1])
+		paired, _ := curve.Pair(pairs[0].G1, pairs[0].G2)
+		assert.True(t, curve.FinalExponentiation(single).Equals(paired))
+
+		empty, ok := curve.MultiMillerLoop([]PairInput{})
+		assert.True(t, ok && curve.FinalExponentiation(empty).Equals(curve.GetGTIdentity()))
+		empty, ok = curve.PairingProduct([]Point{}, []Point{})
+		assert.True(t, ok && empty.Equals(curve.GetGTIdentity()), "Empty pairing product isn't the identity")
+		_, ok = curve.MultiMillerLoop([]PairInput{{curve.GetG2(), curve.GetG1()}})
+		assert.False(t, ok, "Miller loop of swapped groups succeeded")
+		_, ok = curve.PairingProduct([]Point{curve.GetG1()}, []Point{})
+		assert.False(t, ok)
+	}
+}
+
+func TestMulReduced(t *testing.T) {
+	for _, curve := range curves {
+		k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		kPlusOrder := new(big.Int).Add(k, curve.GetG1Order())
+		huge := new(big.Int).Add(k, new(big.Int).Lsh(curve.GetG1Order(), 1000))
+		for _, g := range []Point{curve.GetG1(), curve.GetG2()} {
+			expected := g.Mul(k)
+			assert.True(t, g.MulReduced(k).Equals(g.MulReduced(kPlusOrder)), "MulReduced(k) != MulReduced(k + order)")
+			assert.True(t, g.MulReduced(k).Equals(expected), "MulReduced(k) != Mul(k)")
+			assert.True(t, g.MulReduced(huge).Equals(expected), "MulReduced of a huge scalar differs")
+			negative := new(big.Int).Sub(k, curve.GetG1Order())
+			assert.True(t, g.MulReduced(negative).Equals(expected), "MulReduced of a negative scalar differs")
+		}
+	}
+}
+
+func TestCheckPairing(t *testing.T) {
+	for _, curve := range curves {
+		a, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		b, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		ab := new(big.Int).Mul(a, b)
+		target, _ := curve.Pair(curve.GetG1().Mul(ab), curve.GetG2())
+		assert.True(t, CheckPairingEquals(curve, curve.GetG1().Mul(a), curve.GetG2().Mul(b), target))
+		assert.False(t, CheckPairingEquals(curve, curve.GetG1().Mul(b), curve.GetG2().Mul(b), target))
+		assert.False(t, CheckPairingEquals(curve, curve.GetG1().Mul(a), curve.GetG2().Mul(b), nil))
+
+		as := []Point{curve.GetG1().Mul(a), curve.GetG1().Mul(b)}
+		bs := []Point{curve.GetG2().Mul(b), curve.GetG2().Mul(a)}
+		target, _ = curve.Pair(curve.GetG1().Mul(ab), curve.GetG2().Mul(two))
+		assert.True(t, CheckPairingProduct(curve, as, bs, target))
+		assert.False(t, CheckPairingProduct(curve, as, bs[:1], target))
+		assert.False(t, CheckPairingProduct(curve, as, as, target))
+	}
+}
+
 func TestAggregation(t *testing.T) {
 	for _, curve := range curves {
 		for _, N := range []int{2, 4, 6, 8} {
@@ -185,6 +318,210 @@ func TestAggregation(t *testing.T) {
 	}
 }
 
+// panickingPoint is a point whose Add always panics.
+type panickingPoint struct {
+	Point
+}
+
+func (p panickingPoint) Add(Point) (Point, bool) {
+	panic("panickingPoint.Add")
+}
+
+func TestSafeAggregatePoints(t *testing.T) {
+	for _, curve := range curves {
+		for _, N := range []int{2, 3, 8, 33} {
+			for _, bad := range []int{0, N - 1} {
+				pts := make([]Point, N)
+				for i := 0; i < N; i++ {
+					pts[i] = curve.GetG1()
+				}
+				pts[bad] = panickingPoint{curve.GetG1()}
+				done := make(chan error)
+				go func() {
+					_, err := SafeAggregatePoints(pts)
+					done <- err
+				}()
+				select {
+				case err := <-done:
+					assert.Equal(t, ErrAggregationFailed, err, curve.Name()+" "+strconv.Itoa(N))
+				case <-time.After(5 * time.Second):
+					t.Fatal("Aggregation deadlocked on a panicking point")
+				}
+			}
+			pts := make([]Point, N)
+			for i := 0; i < N; i++ {
+				pts[i] = curve.GetG1()
+			}
+			pts[N-1] = curve.GetG2()
+			_, err := SafeAggregatePoints(pts)
+			assert.Equal(t, ErrAggregationFailed, err, "Aggregating points of different groups succeeded")
+			assert.Nil(t, AggregatePoints(pts))
+		}
+		agg, err := SafeAggregatePoints([]Point{curve.GetG1(), curve.GetG1()})
+		assert.Nil(t, err)
+		assert.True(t, agg.Equals(curve.GetG1().Mul(two)))
+	}
+}
+
+// otherCurve is a copy of a curve system, which counts as a distinct curve.
+type otherCurve struct {
+	CurveSystem
+}
+
+func (c *otherCurve) Name() string {
+	return "other " + c.CurveSystem.Name()
+}
+
+// otherCurvePoint is a point which claims to belong to another curve.
+type otherCurvePoint struct {
+	Point
+	curve CurveSystem
+}
+
+func (p otherCurvePoint) Curve() CurveSystem {
+	return p.curve
+}
+
+func TestCheckCurve(t *testing.T) {
+	for _, curve := range curves {
+		other := &otherCurve{curve}
+		assert.Nil(t, CheckCurve(curve, curve.GetG1(), curve.GetG2(), curve.GetG1Infinity()))
+		assert.Nil(t, CheckCurve(curve))
+		foreign := otherCurvePoint{curve.GetG1(), other}
+		assert.Equal(t, ErrCurveMismatch, CheckCurve(curve, curve.GetG1(), foreign))
+		assert.Equal(t, ErrCurveMismatch, CheckCurve(other, curve.GetG1()))
+		assert.Equal(t, ErrCurveMismatch, CheckCurve(curve, nil))
+
+		for _, N := range []int{2, 8} {
+			pts := make([]Point, N)
+			for i := 0; i < N; i++ {
+				pts[i] = curve.GetG1()
+			}
+			pts[N-1] = foreign
+			_, err := SafeAggregatePoints(pts)
+			assert.Equal(t, ErrCurveMismatch, err, "Aggregated points of different curves")
+		}
+	}
+}
+
+func TestAggregateSinglePoint(t *testing.T) {
+	for _, curve := range curves {
+		for _, g := range []Point{curve.GetG1(), curve.GetG2()} {
+			before := runtime.NumGoroutine()
+			agg := AggregatePoints([]Point{g})
+			assert.Equal(t, before, runtime.NumGoroutine())
+			assert.True(t, agg.Equals(g))
+			// The result is a copy of the point, not the point itself.
+			assert.True(t, agg != g, "Aggregating a single point didn't copy it")
+		}
+		_, err := SafeAggregatePoints([]Point{nil})
+		assert.Equal(t, ErrAggregationFailed, err)
+	}
+}
+
+func TestAggregatePointsUnique(t *testing.T) {
+	for _, curve := range curves {
+		keys := []Point{RandomG2(curve), RandomG2(curve), RandomG2(curve)}
+		sum, n, err := AggregatePointsUnique(keys)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, n)
+		assert.True(t, sum.Equals(AggregatePoints(keys)), "Unique aggregation differs from AggregatePoints")
+
+		_, _, err = AggregatePointsUnique(append(keys, keys[1].Copy()))
+		assert.Equal(t, ErrDuplicatePoint, err, "Duplicated key wasn't detected")
+		_, _, err = AggregatePointsUnique([]Point{keys[0], nil})
+		assert.Equal(t, ErrAggregationFailed, err)
+	}
+}
+
+// outsideSubgroupPoint is a point which claims not to be in its subgroup.
+type outsideSubgroupPoint struct {
+	Point
+}
+
+func (p outsideSubgroupPoint) InCorrectSubgroup() bool {
+	return false
+}
+
+func TestBatchSubgroupCheck(t *testing.T) {
+	for _, curve := range curves {
+		points := []Point{RandomG1(curve), RandomG2(curve), RandomG1(curve), RandomG2(curve), nil}
+		points[2] = outsideSubgroupPoint{points[2]}
+		assert.Equal(t, []bool{true, true, false, true, false}, BatchSubgroupCheck(points))
+		assert.Equal(t, []bool{}, BatchSubgroupCheck([]Point{}))
+	}
+}
+
+func TestAutoTuneAggregation(t *testing.T) {
+	for _, curve := range curves {
+		for _, g := range []Point{curve.GetG1(), curve.GetG2()} {
+			typ := reflect.TypeOf(g)
+			old, tuned := aggregationThresholds.Load(typ)
+			start := time.Now()
+			threshold := AutoTuneAggregation(g)
+			assert.True(t, time.Since(start) < time.Second, "Auto tuning took too long")
+			assert.True(t, threshold >= 2, "Auto tuned threshold is too small")
+			assert.Equal(t, threshold, AggregationThreshold(g))
+			// Check aggregation on both sides of the threshold
+			for _, N := range []int{threshold, threshold + 1} {
+				pts := make([]Point, N)
+				sum := new(big.Int).SetInt64(0)
+				for i := 0; i < N; i++ {
+					x, _ := rand.Int(rand.Reader, curve.GetG1Order())
+					sum.Add(sum, x)
+					pts[i] = g.Mul(x)
+				}
+				assert.True(t, AggregatePoints(pts).Equals(g.Mul(sum)), curve.Name()+" "+strconv.Itoa(N))
+			}
+			if tuned {
+				aggregationThresholds.Store(typ, old)
+			} else {
+				aggregationThresholds.Delete(typ)
+			}
+		}
+	}
+}
+
+func TestHashToG1Batch(t *testing.T) {
+	for _, curve := range curves {
+		msgs := make([][]byte, 20)
+		for i := 0; i < len(msgs); i++ {
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+		}
+		pts := HashToG1Batch(curve, msgs)
+		assert.Equal(t, len(msgs), len(pts))
+		for i := 0; i < len(msgs); i++ {
+			assert.True(t, pts[i].Equals(curve.HashToG1(msgs[i])), "Batched hash differs from HashToG1")
+		}
+		assert.Equal(t, 0, len(HashToG1Batch(curve, [][]byte{})))
+	}
+}
+
+func TestHashSetToG1(t *testing.T) {
+	for _, curve := range curves {
+		msgs := make([][]byte, 6)
+		for i := 0; i < len(msgs); i++ {
+			msgs[i] = make([]byte, 32)
+			rand.Read(msgs[i])
+		}
+		set := HashSetToG1(curve, msgs)
+		reversed := make([][]byte, len(msgs))
+		for i := 0; i < len(msgs); i++ {
+			reversed[i] = msgs[len(msgs)-1-i]
+		}
+		assert.True(t, set.Equals(HashSetToG1(curve, reversed)), "Set hash depends on the order")
+
+		incremental := HashSetToG1(curve, [][]byte{})
+		assert.True(t, incremental.Equals(curve.GetG1Infinity()), "Empty set hash isn't the identity")
+		for _, i := range []int{3, 0, 5, 1, 4, 2} {
+			incremental = AddToSetHash(curve, incremental, msgs[i])
+		}
+		assert.True(t, set.Equals(incremental), "Incremental set hash differs")
+		assert.False(t, set.Equals(HashSetToG1(curve, msgs[1:])), "Set hash ignores a message")
+	}
+}
+
 func TestScaling(t *testing.T) {
 	N := 5
 	for _, curve := range curves {
@@ -199,6 +536,14 @@ func TestScaling(t *testing.T) {
 				pts2[i] = pts1[i].Copy().Mul(f)
 				factors[i] = f
 			}
+			for _, workers := range []int{0, 1, 2, N + 1} {
+				scaled := ScalePointsParallel(pts1, factors, workers)
+				for i := 0; i < N; i++ {
+					assert.True(t, scaled[i].Equals(pts2[i]))
+				}
+			}
+			assert.Nil(t, ScalePointsParallel(pts1, factors[1:], 2), "Mismatched lengths weren't rejected")
+			assert.Equal(t, pts1, ScalePointsParallel(pts1, nil, 2), "Nil factors didn't return the points")
 			pts1 = ScalePoints(pts1, factors)
 			for i := 0; i < N; i++ {
 				assert.True(t, pts1[i].Equals(pts2[i]))
@@ -268,3 +613,122 @@ func generateG1HashVectors(curve CurveSystem) {
 func mutativeAppend(s *[]byte, msg []byte) {
 	*s = append(*s, msg...)
 }
+
+func benchmarkMessages(n int) [][]byte {
+	msgs := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		msgs[i] = make([]byte, 64)
+		rand.Read(msgs[i])
+	}
+	return msgs
+}
+
+func BenchmarkHashToG1Batch64(b *testing.B) {
+	msgs := benchmarkMessages(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		HashToG1Batch(Altbn128, msgs)
+	}
+}
+
+func BenchmarkHashToG1Serial64(b *testing.B) {
+	msgs := benchmarkMessages(64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < len(msgs); j++ {
+			Altbn128.HashToG1(msgs[j])
+		}
+	}
+}
+
+func BenchmarkAggregateSinglePoint(b *testing.B) {
+	pts := []Point{Altbn128.GetG2()}
+	b.ReportAllocs()
+	b.ResetTimer()
+	before := runtime.NumGoroutine()
+	for i := 0; i < b.N; i++ {
+		AggregatePoints(pts)
+	}
+	b.ReportMetric(float64(runtime.NumGoroutine()-before), "goroutines")
+}
+
+func benchmarkScalePoints(b *testing.B, scale func([]Point, []*big.Int) []Point) {
+	N := 50000
+	pts := make([]Point, N)
+	factors := make([]*big.Int, N)
+	g := Altbn128.GetG1()
+	for i := 0; i < N; i++ {
+		pts[i] = g
+		factors[i] = big.NewInt(int64(i + 2))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scale(pts, factors)
+	}
+}
+
+func BenchmarkScalePoints50000(b *testing.B) {
+	benchmarkScalePoints(b, ScalePoints)
+}
+
+// BenchmarkScalePointsUnbounded50000 measures the previous scaling, which
+// started one goroutine per point.
+func BenchmarkScalePointsUnbounded50000(b *testing.B) {
+	benchmarkScalePoints(b, func(pts []Point, factors []*big.Int) []Point {
+		scaled := make([]Point, len(pts))
+		var wg sync.WaitGroup
+		wg.Add(len(pts))
+		for i := 0; i < len(pts); i++ {
+			go func(i int) {
+				scaled[i] = pts[i].Mul(factors[i])
+				wg.Done()
+			}(i)
+		}
+		wg.Wait()
+		return scaled
+	})
+}
+
+// BenchmarkPairingProduct2 measures the two pairing product of a single
+// signature's verification, which is computed without starting workers.
+func BenchmarkPairingProduct2(b *testing.B) {
+	g1Points := []Point{Altbn128.GetG1(), Altbn128.GetG1().Neg()}
+	g2Points := []Point{Altbn128.GetG2(), Altbn128.GetG2()}
+	b.ReportAllocs()
+	b.ResetTimer()
+	before := runtime.NumGoroutine()
+	for i := 0; i < b.N; i++ {
+		Altbn128.PairingProduct(g1Points, g2Points)
+	}
+	b.ReportMetric(float64(runtime.NumGoroutine()-before), "goroutines")
+}
+
+// doubledG2Curve is a curve whose G2 generator is twice the standard one.
+type doubledG2Curve struct {
+	CurveSystem
+}
+
+func (curve doubledG2Curve) GetG2() Point {
+	return curve.CurveSystem.GetG2().Mul(big.NewInt(2))
+}
+
+func TestVerifyGenerators(t *testing.T) {
+	for _, curve := range curves {
+		assert.True(t, VerifyGenerators(curve), "Built in generators failed verification")
+		assert.False(t, VerifyGenerators(doubledG2Curve{curve}), "Non standard generator passed verification")
+	}
+}
+
+func TestRandomPoints(t *testing.T) {
+	for _, curve := range curves {
+		for _, random := range []func(CurveSystem) Point{RandomG1, RandomG2} {
+			pt1, pt2 := random(curve), random(curve)
+			assert.True(t, pt1.InCorrectSubgroup() && pt2.InCorrectSubgroup(), "Random point isn't in the subgroup")
+			assert.True(t, pt1.IsOnCurve() && pt2.IsOnCurve(), "Random point isn't on the curve")
+			assert.False(t, pt1.Equals(pt2), "Random points are equal")
+		}
+		assert.False(t, RandomG1(curve).Equals(curve.GetG1Infinity()))
+		assert.False(t, RandomG2(curve).Equals(curve.GetG2Infinity()))
+	}
+}
@@ -5,6 +5,7 @@ package curves
 
 import (
 	"crypto/rand"
+	"hash"
 	"math/big"
 )
 
@@ -76,6 +77,69 @@ func tryAndIncrementEvm(message []byte, hashfunc func(message []byte) [32]byte,
 	return
 }
 
+// expandMessageXMD implements expand_message_xmd from RFC 9380, section 5.3.1,
+// with a caller supplied hash function. The domain separation tag is length
+// suffixed, so distinct (dst, message) pairs never share a hash input.
+// It returns false if lenInBytes is too large for the hash function.
+func expandMessageXMD(message []byte, dst []byte, lenInBytes int, newHash func() hash.Hash) ([]byte, bool) {
+	h := newHash()
+	bInBytes, rInBytes := h.Size(), h.BlockSize()
+	if len(dst) > 255 {
+		h.Write([]byte("H2C-OVERSIZE-DST-"))
+		h.Write(dst)
+		dst = h.Sum(nil)
+		h.Reset()
+	}
+	ell := (lenInBytes + bInBytes - 1) / bInBytes
+	if ell > 255 || lenInBytes > 65535 {
+		return nil, false
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h.Write(make([]byte, rInBytes))
+	h.Write(message)
+	h.Write([]byte{byte(lenInBytes >> 8), byte(lenInBytes), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+	uniform := append(make([]byte, 0, ell*bInBytes), bi...)
+	for i := 2; i <= ell; i++ {
+		h.Reset()
+		for j := range b0 {
+			h.Write([]byte{b0[j] ^ bi[j]})
+		}
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		uniform = append(uniform, bi...)
+	}
+	return uniform[:lenInBytes], true
+}
+
+// hashToFieldG1 implements hash_to_field from RFC 9380, section 5.2, returning
+// count elements of F_q. Each element uses L = ceil((ceil(log2(q)) + k) / 8)
+// bytes of expand_message_xmd output, with k = 128.
+func hashToFieldG1(message []byte, dst []byte, count int, newHash func() hash.Hash,
+	curve CurveSystem) ([]*big.Int, bool) {
+	q := curve.GetG1Q()
+	l := (q.BitLen() + 128 + 7) / 8
+	uniform, ok := expandMessageXMD(message, dst, count*l, newHash)
+	if !ok {
+		return nil, false
+	}
+	u := make([]*big.Int, count)
+	for i := 0; i < count; i++ {
+		u[i] = new(big.Int).SetBytes(uniform[i*l : (i+1)*l])
+		u[i].Mod(u[i], q)
+	}
+	return u, true
+}
+
 func sortBigInts(b1 *big.Int, b2 *big.Int) (*big.Int, *big.Int) {
 	if b1.Cmp(b2) > 0 {
 		return b2, b1
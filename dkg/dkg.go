@@ -164,6 +164,62 @@ func SignatureReconstruction(curve CurveSystem, sigs []Point, signersIndices []*
 	return AggregatePoints(sigsExp), nil
 }
 
+//VerifyThresholdSignature checks a group signature, as reconstructed by
+//SignatureReconstruction from threshold+1 signature shares, against the group
+//public key from GetGroupPublicKey. The group signature is a standard BLS
+//signature by the group secret key, so this is VerifySingleSignature, but it
+//doesn't require the signers' individual public keys.
+func VerifyThresholdSignature(curve CurveSystem, groupPubKey Point, msg []byte, sig Point) bool {
+	return VerifySingleSignature(curve, sig, groupPubKey, msg)
+}
+
+//RecoverSecretKey reconstructs the group secret key out of at least threshold+1
+//secret key shares, by Lagrange interpolation of the shares at 0.
+//indices[i] should be the index of the participant that holds shares[i]
+//threshold is the degree of the sharing polynomial, as in GetAllPublicKey.
+func RecoverSecretKey(curve CurveSystem, threshold int, indices []int, shares []*big.Int) (*big.Int, error) {
+	t1 := len(shares)
+	if threshold < 0 || t1 != len(indices) {
+		return nil, fmt.Errorf("input length error")
+	}
+	if t1 < threshold+1 {
+		return nil, fmt.Errorf("%v shares are too few for threshold %v", t1, threshold)
+	}
+	seen := make(map[int]bool)
+	for i := 0; i < t1; i++ {
+		if indices[i] <= 0 {
+			return nil, fmt.Errorf("index %v is not positive", indices[i])
+		}
+		if seen[indices[i]] {
+			return nil, fmt.Errorf("there are 2 indices that are the same")
+		}
+		seen[indices[i]] = true
+	}
+
+	q := curve.GetG1Order()
+	sk := big.NewInt(0)
+	for i := 0; i < t1; i++ {
+		num := big.NewInt(1)
+		den := big.NewInt(1)
+		xi := big.NewInt(int64(indices[i]))
+		for j := 0; j < t1; j++ {
+			if j != i {
+				xj := big.NewInt(int64(indices[j]))
+				num.Mul(num, xj)
+				num.Mod(num, q)
+				den.Mul(den, big.NewInt(0).Sub(xj, xi))
+				den.Mod(den, q)
+			}
+		}
+		den.ModInverse(den, q)
+		lambda := num.Mul(num, den)
+		lambda.Mul(lambda, shares[i])
+		sk.Add(sk, lambda)
+		sk.Mod(sk, q)
+	}
+	return sk, nil
+}
+
 //Encrypt encrypts a big integer
 func Encrypt(curve CurveSystem, sk *big.Int, decrypterPk Point, dataToEnc *big.Int) *big.Int {
 	return encryptOrDecrypt(curve, sk, decrypterPk, dataToEnc)
@@ -171,6 +171,81 @@ func TestDKGHappyFlow(t *testing.T) {
 	}
 }
 
+func TestVerifyThresholdSignature(t *testing.T) {
+	for _, curve := range curves {
+		// A 3 of 5 sharing has a polynomial of degree 2
+		t1 := 3
+		sk, groupPk, _ := KeyGen(curve)
+		coefs := make([]*big.Int, t1)
+		coefs[0] = sk
+		for i := 1; i < t1; i++ {
+			coefs[i], _ = rand.Int(rand.Reader, curve.GetG1Order())
+		}
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		indices := make([]*big.Int, 5)
+		sigs := make([]Point, 5)
+		for i := 0; i < 5; i++ {
+			indices[i] = big.NewInt(int64(i + 1))
+			sigs[i] = Sign(curve, GetPrivateCommitment(curve, indices[i], coefs), msg)
+		}
+		groupSig, err := SignatureReconstruction(curve, []Point{sigs[4], sigs[0], sigs[2]},
+			[]*big.Int{indices[4], indices[0], indices[2]})
+		assert.Nil(t, err, "group signature reconstruction failed")
+		assert.True(t, VerifyThresholdSignature(curve, groupPk, msg, groupSig), "threshold signature invalid")
+
+		groupSig, _ = SignatureReconstruction(curve, sigs[:t1-1], indices[:t1-1])
+		assert.False(t, VerifyThresholdSignature(curve, groupPk, msg, groupSig),
+			"threshold signature from too few shares is valid")
+	}
+}
+
+func TestRecoverSecretKey(t *testing.T) {
+	for _, curve := range curves {
+		t1 := 3
+		sk, pk, _ := KeyGen(curve)
+		coefs := make([]*big.Int, t1)
+		coefs[0] = sk
+		for i := 1; i < t1; i++ {
+			coefs[i], _ = rand.Int(rand.Reader, curve.GetG1Order())
+		}
+		indices := []int{1, 2, 3, 4, 5}
+		shares := make([]*big.Int, len(indices))
+		for i := 0; i < len(indices); i++ {
+			shares[i] = GetPrivateCommitment(curve, big.NewInt(int64(indices[i])), coefs)
+		}
+
+		recovered, err := RecoverSecretKey(curve, t1-1, indices[:t1], shares[:t1])
+		assert.Nil(t, err, "secret key recovery failed")
+		assert.True(t, recovered.Cmp(sk) == 0, "recovered secret key is different")
+		assert.True(t, LoadPublicKey(curve, recovered).Equals(pk), "recovered secret key doesnt match the public key")
+
+		recovered, err = RecoverSecretKey(curve, t1-1, []int{5, 2, 4}, []*big.Int{shares[4], shares[1], shares[3]})
+		assert.Nil(t, err, "secret key recovery failed")
+		assert.True(t, recovered.Cmp(sk) == 0, "recovered secret key from other shares is different")
+
+		recovered, err = RecoverSecretKey(curve, t1-1, indices, shares)
+		assert.Nil(t, err, "secret key recovery failed")
+		assert.True(t, recovered.Cmp(sk) == 0, "recovered secret key from more than t+1 shares is different")
+
+		_, err = RecoverSecretKey(curve, t1-1, indices[:t1-1], shares[:t1-1])
+		assert.NotNil(t, err, "secret key recovery succeeded with too few shares")
+		_, err = RecoverSecretKey(curve, t1-1, []int{1, 1, 2}, shares[:t1])
+		assert.NotNil(t, err, "secret key recovery succeeded with duplicate indices")
+		_, err = RecoverSecretKey(curve, t1-1, []int{0, 1, 2}, shares[:t1])
+		assert.NotNil(t, err, "secret key recovery succeeded with a zero index")
+		_, err = RecoverSecretKey(curve, t1-1, indices[:t1], shares[:t1-1])
+		assert.NotNil(t, err, "secret key recovery succeeded with mismatched lengths")
+		_, err = RecoverSecretKey(curve, -1, indices[:t1], shares[:t1])
+		assert.NotNil(t, err, "secret key recovery succeeded with a negative threshold")
+
+		// With a threshold of 0 every share is the secret key.
+		recovered, err = RecoverSecretKey(curve, 0, []int{3}, []*big.Int{sk})
+		assert.Nil(t, err, "secret key recovery failed with threshold 0")
+		assert.True(t, recovered.Cmp(sk) == 0, "recovered secret key with threshold 0 is different")
+	}
+}
+
 func TestEncryptionDecryption(t *testing.T) {
 	for _, curve := range curves {
 